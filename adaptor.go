@@ -0,0 +1,155 @@
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Adaptor signatures are signatures "encrypted" under an adaptor point T = t*G:
+// anyone can check that an adaptor signature is valid, but it only becomes an
+// actual signature with t, and whoever sees both learns t. That is what makes
+// atomic swaps and payment channels work.
+//
+// An adaptor signature is 65 bytes: R = k*G + T in compressed form followed by
+// s' = k + e*d, so the signature is (R, s' + t), or (R, s' - t) when R has an
+// odd y and the nonce must be negated.
+
+// EncryptedSign makes an adaptor signature of a 32 byte message, encrypted
+// under the 32 byte adaptor point, in the same format as public keys.
+func EncryptedSign(privateKey *big.Int, message [32]byte, adaptorPoint [32]byte) ([65]byte, error) {
+	adaptorSig := [65]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return adaptorSig, ErrKeyOutOfRange
+	}
+	T, ok := liftX(new(big.Int).SetBytes(adaptorPoint[:]))
+	if !ok {
+		return adaptorSig, errors.New("adaptor point is not a valid point")
+	}
+
+	Px, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+
+	// the nonce must depend on T, reusing the one Sign would pick for the same
+	// message would give the private key away.
+	nonceHash := taggedHash("schnorr/adaptor/nonce", intToByte(d), adaptorPoint[:], intToByte(Px), message[:])
+	k := hashToScalar(nonceHash)
+	if k.Sign() == 0 {
+		return adaptorSig, ErrZeroNonce
+	}
+
+	kGx, kGy := baseMult(k)
+	kG := newAffinePoint(kGx, kGy)
+	var R jacobianPoint
+	R.setAffine(&kG)
+	R.addMixed(&R, &T)
+	if R.isInfinity() {
+		return adaptorSig, ErrZeroNonce
+	}
+	Rx, Ry := R.affine()
+	if Ry.Bit(0) == 1 {
+		k.Sub(Curve.N, k)
+	}
+
+	e := getE(Px, Py, intToByte(Rx), message)
+	e.Mul(e, d)
+	k.Add(k, e)
+	k.Mod(k, Curve.N)
+
+	copy(adaptorSig[:33], compressPoint(&R))
+	intToByteInto(adaptorSig[33:], k)
+	return adaptorSig, nil
+}
+
+// VerifyAdaptor is VerifyEncryptedSignature with the adaptor signature before
+// the adaptor point, for checking the other side's adaptor signature before
+// going ahead with a swap.
+func VerifyAdaptor(publicKey [32]byte, message [32]byte, adaptorSig [65]byte, adaptorPoint [32]byte) (bool, error) {
+	return VerifyEncryptedSignature(publicKey, message, adaptorPoint, adaptorSig)
+}
+
+// VerifyEncryptedSignature verifies an adaptor signature of a 32 byte message
+// against the public key and the adaptor point. If it is valid, t is enough to
+// turn it into a signature that passes Verify.
+func VerifyEncryptedSignature(publicKey [32]byte, message [32]byte, adaptorPoint [32]byte, adaptorSig [65]byte) (bool, error) {
+	Px := new(big.Int).SetBytes(publicKey[:])
+	P, ok := liftX(Px)
+	if !ok {
+		return false, ErrVerifyFailed
+	}
+	T, ok := liftX(new(big.Int).SetBytes(adaptorPoint[:]))
+	if !ok {
+		return false, ErrVerifyFailed
+	}
+	Rj, ok := decompressPoint(adaptorSig[:33])
+	if !ok || Rj.isInfinity() {
+		return false, ErrVerifyFailed
+	}
+	s := new(big.Int).SetBytes(adaptorSig[33:])
+	if s.Cmp(Curve.N) >= 0 {
+		return false, ErrSTooLarge
+	}
+
+	// s'G = ±(R - T) + eP, with the sign of the y of R.
+	Rx, Ry := Rj.affine()
+	R := newAffinePoint(Rx, Ry)
+	e := getE(Px, P.y.int(), intToByte(Rx), message)
+	minusOne := new(big.Int).Sub(Curve.N, One)
+	rScalar, tScalar := minusOne, One
+	if Ry.Bit(0) == 1 {
+		rScalar, tScalar = One, minusOne
+	}
+
+	result := multiScalarMult(
+		[]affinePoint{newAffinePoint(Curve.Gx, Curve.Gy), P, R, T},
+		[]*big.Int{s, e.Sub(Curve.N, e), rScalar, tScalar},
+	)
+	if !result.isInfinity() {
+		return false, ErrVerifyFailed
+	}
+	return true, nil
+}
+
+// DecryptSignature turns an adaptor signature into a 64 byte signature with t,
+// the discrete logarithm of the adaptor point.
+func DecryptSignature(adaptorSig [65]byte, t *big.Int) ([64]byte, error) {
+	sig := [64]byte{}
+	if t.Cmp(One) < 0 || t.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return sig, errors.New("the adaptor secret must be an integer in the range 1..n-1")
+	}
+
+	// the adaptor point is the one with the even y, which may be -t*G.
+	t = new(big.Int).Set(t)
+	if _, Ty := baseMult(t); Ty.Bit(0) == 1 {
+		t.Sub(Curve.N, t)
+	}
+	if adaptorSig[0] == 3 {
+		t.Sub(Curve.N, t)
+	}
+
+	s := new(big.Int).SetBytes(adaptorSig[33:])
+	s.Add(s, t)
+	copy(sig[:32], adaptorSig[1:33])
+	intToByteInto(sig[32:], s.Mod(s, Curve.N))
+	return sig, nil
+}
+
+// RecoverAdaptorSecret returns t, the discrete logarithm of the adaptor point,
+// given an adaptor signature and the signature decrypted from it. Whoever
+// published the signature can't have changed it into another valid one first:
+// r fixes R and s has to be below n, so a BIP340 signature has a single
+// encoding. Check the signature with Verify before trusting the result.
+func RecoverAdaptorSecret(signature [64]byte, adaptorSig [65]byte) (*big.Int, error) {
+	if string(signature[:32]) != string(adaptorSig[1:33]) {
+		return nil, errors.New("the signature was not made from this adaptor signature")
+	}
+
+	t := new(big.Int).SetBytes(signature[32:])
+	t.Sub(t, new(big.Int).SetBytes(adaptorSig[33:]))
+	if adaptorSig[0] == 3 {
+		t.Neg(t)
+	}
+	return t.Mod(t, Curve.N), nil
+}
@@ -0,0 +1,107 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestAdaptorSignatures(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "paid once the secret is revealed")
+
+	// a few rounds so both parities of R and of t*G come up.
+	for i := 0; i < 8; i++ {
+		d, publicKey, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		secret, adaptorPoint, _ := GenerateKeyPair(nil)
+
+		adaptorSig, err := EncryptedSign(d, message, adaptorPoint)
+		if err != nil {
+			t.Fatalf("Unexpected error from EncryptedSign: %v", err)
+		}
+		if ok, err := VerifyEncryptedSignature(publicKey, message, adaptorPoint, adaptorSig); !ok {
+			t.Fatalf("VerifyEncryptedSignature failed: %v", err)
+		}
+		other := adaptorPoint
+		other[31] ^= 1
+		if ok, _ := VerifyEncryptedSignature(publicKey, message, other, adaptorSig); ok {
+			t.Fatalf("VerifyEncryptedSignature succeeded for the wrong adaptor point")
+		}
+
+		// the adaptor signature alone is not a signature.
+		var notASignature [64]byte
+		copy(notASignature[:], adaptorSig[1:])
+		if ok, _ := Verify(publicKey, message, notASignature); ok {
+			t.Fatalf("Verify succeeded for an adaptor signature")
+		}
+
+		signature, err := DecryptSignature(adaptorSig, secret)
+		if err != nil {
+			t.Fatalf("Unexpected error from DecryptSignature: %v", err)
+		}
+		if ok, err := Verify(publicKey, message, signature); !ok {
+			t.Fatalf("Verify of the decrypted signature failed: %v", err)
+		}
+
+		recovered, err := RecoverAdaptorSecret(signature, adaptorSig)
+		if err != nil {
+			t.Fatalf("Unexpected error from RecoverAdaptorSecret: %v", err)
+		}
+		if Tx, Ty := baseMult(recovered); Ty.Bit(0) == 1 || string(intToByte(Tx)) != string(adaptorPoint[:]) {
+			t.Fatalf("RecoverAdaptorSecret = %x, which is not the adaptor point %x", recovered, adaptorPoint)
+		}
+	}
+}
+
+func TestAdaptorSwap(t *testing.T) {
+	// Alice and Bob swap coins on two chains. Alice knows t, both know T, and
+	// each spend needs a signature from the one giving the coins away.
+	alice, alicePublicKey, _ := GenerateKeyPair(nil)
+	bob, bobPublicKey, _ := GenerateKeyPair(nil)
+	secret, adaptorPoint, _ := GenerateKeyPair(nil)
+	var toAlice, toBob [32]byte
+	copy(toAlice[:], "bob pays alice on chain one")
+	copy(toBob[:], "alice pays bob on chain two")
+
+	// both give the other an adaptor signature and check the one they get.
+	bobAdaptorSig, err := EncryptedSign(bob, toAlice, adaptorPoint)
+	if err != nil {
+		t.Fatalf("Unexpected error from EncryptedSign: %v", err)
+	}
+	aliceAdaptorSig, err := EncryptedSign(alice, toBob, adaptorPoint)
+	if err != nil {
+		t.Fatalf("Unexpected error from EncryptedSign: %v", err)
+	}
+	if ok, err := VerifyAdaptor(bobPublicKey, toAlice, bobAdaptorSig, adaptorPoint); !ok {
+		t.Fatalf("Alice can't verify Bob's adaptor signature: %v", err)
+	}
+	if ok, err := VerifyAdaptor(alicePublicKey, toBob, aliceAdaptorSig, adaptorPoint); !ok {
+		t.Fatalf("Bob can't verify Alice's adaptor signature: %v", err)
+	}
+
+	// Alice takes her coins, which publishes Bob's signature.
+	published, err := DecryptSignature(bobAdaptorSig, secret)
+	if err != nil {
+		t.Fatalf("Unexpected error from DecryptSignature: %v", err)
+	}
+	if ok, err := Verify(bobPublicKey, toAlice, published); !ok {
+		t.Fatalf("Verify of the published signature failed: %v", err)
+	}
+
+	// which gives Bob t, and with it his coins.
+	recovered, err := RecoverAdaptorSecret(published, bobAdaptorSig)
+	if err != nil {
+		t.Fatalf("Unexpected error from RecoverAdaptorSecret: %v", err)
+	}
+	if Tx, _ := baseMult(recovered); string(intToByte(Tx)) != string(adaptorPoint[:]) {
+		t.Fatalf("RecoverAdaptorSecret = %x, but that times G is not T = %x", recovered, adaptorPoint)
+	}
+	signature, err := DecryptSignature(aliceAdaptorSig, recovered)
+	if err != nil {
+		t.Fatalf("Unexpected error from DecryptSignature: %v", err)
+	}
+	if ok, err := Verify(alicePublicKey, toBob, signature); !ok {
+		t.Fatalf("Verify of Bob's signature with the recovered secret failed: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package schnorr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// AggregateSignaturesSameMessage combines the BIP340 signatures of many signers
+// over the same message into one, by adding up all the s values. Each
+// signature has its own challenge e = hash(R || P || m), so the R values can
+// not be added up as well and the result is 32 * (n + 1) bytes: all the r
+// values followed by the combined s. That is still about half the size.
+//
+// Before being added each s is multiplied by a coefficient derived from all the
+// signatures, otherwise invalid signatures could be made to cancel each other
+// out. This follows the half-aggregation scheme proposed for Bitcoin.
+//
+// It is up to the caller to make sure the public keys really belong to the
+// signers. AggregateVerify only proves that the keys in the list signed.
+func AggregateSignaturesSameMessage(publicKeys [][32]byte, message [32]byte, signatures [][64]byte) ([]byte, error) {
+	if len(publicKeys) == 0 {
+		return nil, errors.New("publicKeys must be an array with one or more elements")
+	}
+	if len(signatures) != len(publicKeys) {
+		return nil, errors.New("all parameters must be an array with the same length")
+	}
+
+	aggSig := make([]byte, 32*(len(signatures)+1))
+	s := new(big.Int)
+	z := aggregateCoefficients(publicKeys, message)
+	for i, signature := range signatures {
+		if ok, err := Verify(publicKeys[i], message, signature); !ok {
+			return nil, fmt.Errorf("signature %d: %w", i, err)
+		}
+		copy(aggSig[32*i:], signature[:32])
+		si := new(big.Int).SetBytes(signature[32:])
+		s.Add(s, si.Mul(si, z(i, aggSig[:32*(i+1)])))
+	}
+
+	intToByteInto(aggSig[32*len(signatures):], s.Mod(s, Curve.N))
+	return aggSig, nil
+}
+
+// AggregateVerify verifies a signature made by AggregateSignaturesSameMessage.
+func AggregateVerify(publicKeys [][32]byte, message [32]byte, aggSig []byte) (bool, error) {
+	if len(publicKeys) == 0 {
+		return false, errors.New("publicKeys must be an array with one or more elements")
+	}
+	if len(aggSig) != 32*(len(publicKeys)+1) {
+		return false, fmt.Errorf("aggregate signature must be %d bytes, not %d", 32*(len(publicKeys)+1), len(aggSig))
+	}
+
+	s := new(big.Int).SetBytes(aggSig[32*len(publicKeys):])
+	if s.Cmp(Curve.N) >= 0 {
+		return false, ErrSTooLarge
+	}
+
+	// s*G = z0(R0 + e0P0) + z1(R1 + e1P1) + ...
+	points := make([]affinePoint, 0, 2*len(publicKeys)+1)
+	scalars := make([]*big.Int, 0, 2*len(publicKeys)+1)
+	z := aggregateCoefficients(publicKeys, message)
+	for i := range publicKeys {
+		Px := new(big.Int).SetBytes(publicKeys[i][:])
+		P, ok := liftX(Px)
+		if !ok {
+			return false, fmt.Errorf("public key %d: %w", i, ErrInvalidPublicKey)
+		}
+		rX := aggSig[32*i : 32*(i+1)]
+		r := new(big.Int).SetBytes(rX)
+		if r.Cmp(Curve.P) >= 0 {
+			return false, fmt.Errorf("signature %d: %w", i, ErrRTooLarge)
+		}
+		R, ok := liftX(r)
+		if !ok {
+			return false, ErrVerifyFailed
+		}
+
+		zi := z(i, aggSig[:32*(i+1)])
+		e := getE(Px, P.y.int(), rX, message)
+		points = append(points, R, P)
+		scalars = append(scalars, zi, e.Mul(e, zi))
+	}
+	points = append(points, newAffinePoint(Curve.Gx, Curve.Gy))
+	scalars = append(scalars, s.Sub(Curve.N, s))
+
+	if result := multiScalarMult(points, scalars); !result.isInfinity() {
+		return false, ErrVerifyFailed
+	}
+	return true, nil
+}
+
+// aggregateCoefficients returns a function giving the coefficient for the
+// signature at index i, given all the r values up to and including it. The
+// first one is 1, the others hash every r, public key and message so far.
+func aggregateCoefficients(publicKeys [][32]byte, message [32]byte) func(i int, rs []byte) *big.Int {
+	return func(i int, rs []byte) *big.Int {
+		if i == 0 {
+			return One
+		}
+		data := make([][]byte, 0, 3*(i+1))
+		for j := 0; j <= i; j++ {
+			data = append(data, rs[32*j:32*(j+1)], publicKeys[j][:], message[:])
+		}
+		return hashToScalar(taggedHash("HalfAgg/randomizer", data...))
+	}
+}
@@ -0,0 +1,51 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestAggregateSignaturesSameMessage(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "everybody signs this")
+	publicKeys := make([][32]byte, 5)
+	signatures := make([][64]byte, 5)
+	for i := range signatures {
+		d, pk, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		publicKeys[i] = pk
+		signatures[i], _ = Sign(d, message, nil)
+	}
+
+	aggSig, err := AggregateSignaturesSameMessage(publicKeys, message, signatures)
+	if err != nil {
+		t.Fatalf("Unexpected error from AggregateSignaturesSameMessage: %v", err)
+	}
+	if len(aggSig) != 32*6 {
+		t.Fatalf("aggregate signature has %d bytes, want %d", len(aggSig), 32*6)
+	}
+	if ok, err := AggregateVerify(publicKeys, message, aggSig); !ok {
+		t.Fatalf("AggregateVerify failed: %v", err)
+	}
+
+	// a different message, a missing key or keys in another order must fail.
+	other := message
+	other[0] ^= 1
+	if ok, _ := AggregateVerify(publicKeys, other, aggSig); ok {
+		t.Fatalf("AggregateVerify succeeded for the wrong message")
+	}
+	if ok, _ := AggregateVerify(publicKeys[1:], message, aggSig[32:]); ok {
+		t.Fatalf("AggregateVerify succeeded without one of the signatures")
+	}
+	publicKeys[0], publicKeys[1] = publicKeys[1], publicKeys[0]
+	if ok, _ := AggregateVerify(publicKeys, message, aggSig); ok {
+		t.Fatalf("AggregateVerify succeeded with the keys swapped")
+	}
+
+	// one invalid signature can't be aggregated.
+	signatures[2][63] ^= 1
+	if _, err := AggregateSignaturesSameMessage(publicKeys, message, signatures); err == nil {
+		t.Fatalf("AggregateSignaturesSameMessage accepted an invalid signature")
+	}
+}
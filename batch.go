@@ -0,0 +1,287 @@
+package schnorr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// BatchVerify verifies a list of 64 byte signatures of 32 byte messages against
+// the public keys all at once, which is faster than calling Verify for each of
+// them. Returns an error if verification fails, naming the index of the first
+// bad signature, which is a *BatchError with all of them unless the bad one
+// couldn't even be decoded.
+// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki#batch-verification
+func BatchVerify(publicKeys [][32]byte, messages [][32]byte, signatures [][64]byte) (bool, error) {
+	return BatchVerifyContext(context.Background(), publicKeys, messages, signatures)
+}
+
+// BatchVerifyContext is the same as BatchVerify, but gives up as soon as it
+// notices ctx is done, returning ctx.Err(). ctx is checked before each
+// signature is added, between the windows of the multi-scalar multiplication
+// (a few dozen times, each one doing a point addition per signature) and
+// before each signature is checked again if the batch fails.
+func BatchVerifyContext(ctx context.Context, publicKeys [][32]byte, messages [][32]byte, signatures [][64]byte) (bool, error) {
+	if err := checkBatch(publicKeys, messages, signatures); err != nil {
+		return false, err
+	}
+
+	b := newBatch(len(signatures))
+	for i := range signatures {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if err := b.add(i, publicKeys[i], messages[i], signatures[i]); err != nil {
+			return false, err
+		}
+	}
+	result, err := b.result(ctx)
+	if err != nil {
+		return false, err
+	}
+	return finishBatch(ctx, result, publicKeys, messages, signatures)
+}
+
+// BatchVerifyParallel is the same as BatchVerifyContext, but splits the work
+// between as many goroutines as GOMAXPROCS allows.
+func BatchVerifyParallel(ctx context.Context, publicKeys [][32]byte, messages [][32]byte, signatures [][64]byte) (bool, error) {
+	if err := checkBatch(publicKeys, messages, signatures); err != nil {
+		return false, err
+	}
+
+	// don't bother splitting in parts that are too small to pay off.
+	n := len(signatures)
+	workers := runtime.GOMAXPROCS(0)
+	if max := (n + 63) / 64; workers > max {
+		workers = max
+	}
+	size := (n + workers - 1) / workers
+	// rounding size up can leave the last workers with nothing to do.
+	workers = (n + size - 1) / size
+
+	results := make([]jacobianPoint, workers)
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			start, end := w*size, (w+1)*size
+			if end > n {
+				end = n
+			}
+
+			b := newBatch(end - start)
+			for i := start; i < end; i++ {
+				if err := ctx.Err(); err != nil {
+					errs[w] = err
+					return
+				}
+				if err := b.add(i, publicKeys[i], messages[i], signatures[i]); err != nil {
+					errs[w] = err
+					return
+				}
+			}
+			results[w], errs[w] = b.result(ctx)
+		}(w)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	// the parts are in order, so this is the same error BatchVerify would give.
+	result := jacobianPoint{}
+	for w := range results {
+		if errs[w] != nil {
+			return false, errs[w]
+		}
+		result.add(&result, &results[w])
+	}
+	return finishBatch(ctx, result, publicKeys, messages, signatures)
+}
+
+// VerifyManyFromKey verifies a list of 64 byte signatures of 32 byte messages,
+// all made by the same public key, like BatchVerify. The public key is only
+// decoded and multiplied once for all of them.
+func VerifyManyFromKey(publicKey [32]byte, messages [][32]byte, signatures [][64]byte) (bool, error) {
+	publicKeys := make([][32]byte, len(signatures))
+	for i := range publicKeys {
+		publicKeys[i] = publicKey
+	}
+	return BatchVerify(publicKeys, messages, signatures)
+}
+
+// VerifySameMessage verifies a list of 64 byte signatures of the same 32 byte
+// message, each made by the public key at the same index, like BatchVerify.
+// There is not much to share between them: the challenge of each signature
+// hashes its own R and public key along with the message, so it has to be
+// computed for every signature anyway. The gain over calling Verify for each
+// of them comes from the single multi-scalar multiplication of BatchVerify.
+func VerifySameMessage(publicKeys [][32]byte, message [32]byte, signatures [][64]byte) (bool, error) {
+	messages := make([][32]byte, len(signatures))
+	for i := range messages {
+		messages[i] = message
+	}
+	return BatchVerify(publicKeys, messages, signatures)
+}
+
+func checkBatch(publicKeys [][32]byte, messages [][32]byte, signatures [][64]byte) error {
+	if len(publicKeys) == 0 {
+		return errors.New("publicKeys must be an array with one or more elements")
+	}
+	if len(messages) != len(publicKeys) || len(signatures) != len(publicKeys) {
+		return errors.New("all parameters must be an array with the same length")
+	}
+	return nil
+}
+
+// batch holds the terms of the batch verification equation
+//
+//	(a1s1 + ... + ausu)G = R1 + a2R2 + ... + auRu + e1P1 + (a2e2)P2 + ... + (aueu)Pu
+//
+// for some of the signatures.
+//
+// When the same public key shows up in a row its terms are merged, so P is
+// only multiplied once for all of its signatures.
+type batch struct {
+	points  []affinePoint
+	scalars []*big.Int
+	sum     *big.Int
+
+	// lastKey is the public key of the last signature added, and lastKeyIndex
+	// the index of its term, or -1 if there is none yet.
+	lastKey      [32]byte
+	lastKeyIndex int
+}
+
+func newBatch(n int) *batch {
+	return &batch{
+		points:       make([]affinePoint, 0, 2*n+1),
+		scalars:      make([]*big.Int, 0, 2*n+1),
+		sum:          new(big.Int),
+		lastKeyIndex: -1,
+	}
+}
+
+// add the terms for the signature at index i.
+func (b *batch) add(i int, publicKey [32]byte, message [32]byte, signature [64]byte) error {
+	Px := new(big.Int).SetBytes(publicKey[:])
+	sameKey := b.lastKeyIndex >= 0 && publicKey == b.lastKey
+	var P affinePoint
+	if sameKey {
+		P = b.points[b.lastKeyIndex]
+	} else {
+		var ok bool
+		if P, ok = liftX(Px); !ok {
+			return fmt.Errorf("signature %d: %w", i, ErrVerifyFailed)
+		}
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	if r.Cmp(Curve.P) >= 0 {
+		return fmt.Errorf("signature %d: %w", i, ErrRTooLarge)
+	}
+	s := new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(Curve.N) >= 0 {
+		return fmt.Errorf("signature %d: %w", i, ErrSTooLarge)
+	}
+	if s.Sign() == 0 {
+		// rejected by Verify as well.
+		return fmt.Errorf("signature %d: %w", i, ErrVerifyFailed)
+	}
+	R, ok := liftX(r)
+	if !ok {
+		return fmt.Errorf("signature %d: %w", i, ErrVerifyFailed)
+	}
+
+	// the first coefficient can be 1, the others must be random so that
+	// invalid signatures can't cancel each other out.
+	a := One
+	if i != 0 {
+		var err error
+		if a, err = deterministicGetRandA(); err != nil {
+			return err
+		}
+	}
+
+	// r and Px are still in their encoded form, so hash those directly.
+	e := challenge(signature[:32], publicKey[:], message[:])
+	e.Mul(e, a)
+	s.Mul(s, a)
+	b.sum.Add(b.sum, s)
+
+	if sameKey {
+		b.scalars[b.lastKeyIndex].Add(b.scalars[b.lastKeyIndex], e)
+		b.points = append(b.points, R)
+		b.scalars = append(b.scalars, a)
+		return nil
+	}
+	b.points = append(b.points, R, P)
+	b.scalars = append(b.scalars, a, e)
+	b.lastKey, b.lastKeyIndex = publicKey, len(b.points)-1
+	return nil
+}
+
+// result moves the left side over, so the results of all the batches must add
+// up to the point at infinity.
+func (b *batch) result(ctx context.Context) (jacobianPoint, error) {
+	b.sum.Mod(b.sum, Curve.N)
+	points := append(b.points, newAffinePoint(Curve.Gx, Curve.Gy))
+	scalars := append(b.scalars, new(big.Int).Sub(Curve.N, b.sum))
+	return multiScalarMultContext(ctx, points, scalars)
+}
+
+func finishBatch(ctx context.Context, result jacobianPoint, publicKeys [][32]byte, messages [][32]byte, signatures [][64]byte) (bool, error) {
+	if !result.isInfinity() {
+		// find out which ones are wrong.
+		batchErr := &BatchError{}
+		for i := range signatures {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			if _, err := Verify(publicKeys[i], messages[i], signatures[i]); err != nil {
+				batchErr.Indices = append(batchErr.Indices, i)
+				batchErr.Errs = append(batchErr.Errs, err)
+			}
+		}
+		if len(batchErr.Indices) == 0 {
+			return false, ErrVerifyFailed
+		}
+		return false, batchErr
+	}
+	return true, nil
+}
+
+// BatchError is returned by the batch verification functions when the batch
+// doesn't verify as a whole, listing every signature that doesn't verify on
+// its own. Signatures that can't even be decoded are reported as soon as they
+// are seen instead, with just their index.
+type BatchError struct {
+	// Indices are the indices of the invalid signatures, in order.
+	Indices []int
+	// Errs are the errors Verify returns for each of them.
+	Errs []error
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Indices) == 0 || len(e.Errs) == 0 {
+		return ErrVerifyFailed.Error()
+	}
+	msg := fmt.Sprintf("signature %d: %v", e.Indices[0], e.Errs[0])
+	if len(e.Indices) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Indices)-1)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the first invalid signature, or nil if there
+// are none.
+func (e *BatchError) Unwrap() error {
+	if len(e.Errs) == 0 {
+		return nil
+	}
+	return e.Errs[0]
+}
@@ -0,0 +1,349 @@
+package schnorr
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func makeBatch(n int, t testing.TB) ([][32]byte, [][32]byte, [][64]byte) {
+	publicKeys := make([][32]byte, n)
+	messages := make([][32]byte, n)
+	signatures := make([][64]byte, n)
+
+	for i := 0; i < n; i++ {
+		d, err := rand.Int(rand.Reader, N2)
+		if err != nil {
+			t.Fatalf("Unexpected error from rand.Int: %v", err)
+		}
+		d.Add(d, One)
+		rand.Read(messages[i][:])
+
+		publicKeys[i], err = GetPublicKey(d)
+		if err != nil {
+			t.Fatalf("Unexpected error from GetPublicKey: %v", err)
+		}
+		signatures[i], err = Sign(d, messages[i], nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from Sign: %v", err)
+		}
+	}
+	return publicKeys, messages, signatures
+}
+
+func TestBatchVerify(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 40} {
+		publicKeys, messages, signatures := makeBatch(n, t)
+		if ok, err := BatchVerify(publicKeys, messages, signatures); !ok {
+			t.Fatalf("BatchVerify of %d valid signatures failed: %v", n, err)
+		}
+
+		messages[n/2][0] ^= 0xff
+		ok, err := BatchVerify(publicKeys, messages, signatures)
+		if ok {
+			t.Fatalf("BatchVerify of %d signatures with a bad one succeeded", n)
+		}
+		if !strings.HasPrefix(err.Error(), "signature "+big.NewInt(int64(n/2)).String()+":") {
+			t.Fatalf("BatchVerify returned an error for the wrong index: %v", err)
+		}
+	}
+
+	publicKeys, messages, signatures := makeBatch(3, t)
+	if ok, _ := BatchVerify(publicKeys, messages[:2], signatures); ok {
+		t.Fatalf("BatchVerify with mismatched lengths succeeded")
+	}
+	if ok, _ := BatchVerify(nil, nil, nil); ok {
+		t.Fatalf("BatchVerify with no signatures succeeded")
+	}
+}
+
+func TestVerifyManyFromKey(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	messages := make([][32]byte, 20)
+	signatures := make([][64]byte, 20)
+	for i := range messages {
+		rand.Read(messages[i][:])
+		signatures[i], _ = Sign(d, messages[i], nil)
+	}
+	if ok, err := VerifyManyFromKey(publicKey, messages, signatures); !ok {
+		t.Fatalf("VerifyManyFromKey of valid signatures failed: %v", err)
+	}
+
+	messages[13][0] ^= 0xff
+	if _, err := VerifyManyFromKey(publicKey, messages, signatures); err == nil || !strings.HasPrefix(err.Error(), "signature 13:") {
+		t.Fatalf("VerifyManyFromKey returned an error for the wrong index: %v", err)
+	}
+	messages[13][0] ^= 0xff
+
+	// runs of the same key mixed with other keys in BatchVerify.
+	others, otherMessages, otherSignatures := makeBatch(2, t)
+	publicKeys := [][32]byte{publicKey, publicKey, others[0], publicKey, others[1], others[1]}
+	messages = [][32]byte{messages[0], messages[1], otherMessages[0], messages[2], otherMessages[1], otherMessages[1]}
+	signatures = [][64]byte{signatures[0], signatures[1], otherSignatures[0], signatures[2], otherSignatures[1], otherSignatures[1]}
+	if ok, err := BatchVerify(publicKeys, messages, signatures); !ok {
+		t.Fatalf("BatchVerify with repeated keys failed: %v", err)
+	}
+	publicKeys[1] = others[0]
+	if ok, _ := BatchVerify(publicKeys, messages, signatures); ok {
+		t.Fatalf("BatchVerify succeeded with a signature under the wrong key")
+	}
+}
+
+func TestVerifySameMessage(t *testing.T) {
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	publicKeys := make([][32]byte, 20)
+	signatures := make([][64]byte, 20)
+	for i := range publicKeys {
+		var d *big.Int
+		d, publicKeys[i], _ = GenerateKeyPair(nil)
+		signatures[i], _ = Sign(d, message, nil)
+	}
+	if ok, err := VerifySameMessage(publicKeys, message, signatures); !ok {
+		t.Fatalf("VerifySameMessage of valid signatures failed: %v", err)
+	}
+
+	signatures[7], signatures[8] = signatures[8], signatures[7]
+	if _, err := VerifySameMessage(publicKeys, message, signatures); err == nil || !strings.HasPrefix(err.Error(), "signature 7:") {
+		t.Fatalf("VerifySameMessage returned an error for the wrong index: %v", err)
+	}
+	signatures[7], signatures[8] = signatures[8], signatures[7]
+
+	message[0] ^= 0xff
+	if ok, _ := VerifySameMessage(publicKeys, message, signatures); ok {
+		t.Fatalf("VerifySameMessage succeeded with another message")
+	}
+}
+
+// countdownContext is done after Err is called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestBatchError(t *testing.T) {
+	publicKeys, messages, signatures := makeBatch(20, t)
+	messages[3][0] ^= 0xff
+	messages[11][0] ^= 0xff
+	messages[19][0] ^= 0xff
+
+	_, err := BatchVerify(publicKeys, messages, signatures)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("BatchVerify = %v, want a *BatchError", err)
+	}
+	if len(batchErr.Indices) != 3 || batchErr.Indices[0] != 3 || batchErr.Indices[1] != 11 || batchErr.Indices[2] != 19 {
+		t.Fatalf("BatchError.Indices = %v, want [3 11 19]", batchErr.Indices)
+	}
+	if !errors.Is(err, ErrVerifyFailed) {
+		t.Fatalf("BatchVerify = %v, want it to wrap %v", err, ErrVerifyFailed)
+	}
+	if expected := "signature 3: signature verification failed (and 2 more)"; err.Error() != expected {
+		t.Fatalf("BatchError.Error() = %q, want %q", err.Error(), expected)
+	}
+
+	// the same from the parallel version.
+	_, err = BatchVerifyParallel(context.Background(), publicKeys, messages, signatures)
+	if !errors.As(err, &batchErr) || len(batchErr.Indices) != 3 {
+		t.Fatalf("BatchVerifyParallel = %v, want a *BatchError with 3 indices", err)
+	}
+
+	// the zero value doesn't panic.
+	empty := &BatchError{}
+	if msg := empty.Error(); msg != ErrVerifyFailed.Error() {
+		t.Fatalf("BatchError{}.Error() = %q, want %q", msg, ErrVerifyFailed.Error())
+	}
+	if errors.Is(empty, ErrVerifyFailed) || errors.Unwrap(empty) != nil {
+		t.Fatalf("BatchError{} unwraps to %v, want nil", errors.Unwrap(empty))
+	}
+}
+
+func TestBatchVerifyContext(t *testing.T) {
+	publicKeys, messages, signatures := makeBatch(50, t)
+	if ok, err := BatchVerifyContext(context.Background(), publicKeys, messages, signatures); !ok {
+		t.Fatalf("BatchVerifyContext of valid signatures failed: %v", err)
+	}
+
+	// in the middle of adding the signatures, then in the middle of the
+	// multi-scalar multiplication.
+	for _, n := range []int{10, len(signatures) + 2} {
+		ctx := &countdownContext{context.Background(), n}
+		if _, err := BatchVerifyContext(ctx, publicKeys, messages, signatures); !errors.Is(err, context.Canceled) {
+			t.Fatalf("BatchVerifyContext cancelled after %d checks = %v, want context.Canceled", n, err)
+		}
+	}
+}
+
+func TestBatchVerifyParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	ctx := context.Background()
+
+	publicKeys, messages, signatures := makeBatch(300, t)
+	if ok, err := BatchVerifyParallel(ctx, publicKeys, messages, signatures); !ok {
+		t.Fatalf("BatchVerifyParallel of valid signatures failed: %v", err)
+	}
+
+	// same errors as the serial version.
+	messages[200][0] ^= 0xff
+	copy(signatures[250][32:], intToByte(Curve.N))
+	_, expected := BatchVerify(publicKeys, messages, signatures)
+	if _, err := BatchVerifyParallel(ctx, publicKeys, messages, signatures); err == nil || err.Error() != expected.Error() {
+		t.Fatalf("BatchVerifyParallel = %v, want %v", err, expected)
+	}
+	copy(signatures[250][32:], intToByte(One))
+	_, expected = BatchVerify(publicKeys, messages, signatures)
+	if _, err := BatchVerifyParallel(ctx, publicKeys, messages, signatures); err == nil || err.Error() != expected.Error() {
+		t.Fatalf("BatchVerifyParallel = %v, want %v", err, expected)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := BatchVerifyParallel(cancelled, publicKeys, messages, signatures); !errors.Is(err, context.Canceled) {
+		t.Fatalf("BatchVerifyParallel with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestBatchVerifyParallelUnevenSplit(t *testing.T) {
+	// 67 parts of 65 signatures would start the last one past the end.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(67))
+	ctx := context.Background()
+
+	someKeys, someMessages, someSignatures := makeBatch(10, t)
+	n := 4289
+	publicKeys := make([][32]byte, n)
+	messages := make([][32]byte, n)
+	signatures := make([][64]byte, n)
+	for i := 0; i < n; i++ {
+		publicKeys[i], messages[i], signatures[i] = someKeys[i%10], someMessages[i%10], someSignatures[i%10]
+	}
+	if ok, err := BatchVerifyParallel(ctx, publicKeys, messages, signatures); !ok {
+		t.Fatalf("BatchVerifyParallel of %d valid signatures failed: %v", n, err)
+	}
+
+	// one that can't be decoded is reported without checking each signature.
+	copy(signatures[n-1][32:], intToByte(Curve.N))
+	_, expected := BatchVerify(publicKeys, messages, signatures)
+	if _, err := BatchVerifyParallel(ctx, publicKeys, messages, signatures); err == nil || err.Error() != expected.Error() {
+		t.Fatalf("BatchVerifyParallel = %v, want %v", err, expected)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(1, b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Verify(publicKeys[0], messages[0], signatures[0])
+	}
+}
+
+func BenchmarkVerifyParallel(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(1, b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Verify(publicKeys[0], messages[0], signatures[0])
+		}
+	})
+}
+
+func BenchmarkVerifyBool(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(1, b)
+	bad := signatures[0]
+	bad[63] ^= 1
+	for _, test := range []struct {
+		name      string
+		signature [64]byte
+	}{{"valid", signatures[0]}, {"invalid", bad}} {
+		b.Run(test.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				VerifyBool(publicKeys[0], messages[0], test.signature)
+			}
+		})
+	}
+}
+
+func BenchmarkBatchVerify(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(200, b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchVerify(publicKeys, messages, signatures)
+	}
+}
+
+func BenchmarkVerifyManyFromKey(b *testing.B) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	for _, n := range []int{100, 1000} {
+		messages := make([][32]byte, n)
+		signatures := make([][64]byte, n)
+		for i := range messages {
+			rand.Read(messages[i][:])
+			signatures[i], _ = Sign(d, messages[i], nil)
+		}
+		b.Run(fmt.Sprintf("%d/Verify", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j := range messages {
+					Verify(publicKey, messages[j], signatures[j])
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%d/VerifyManyFromKey", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifyManyFromKey(publicKey, messages, signatures)
+			}
+		})
+	}
+}
+
+func BenchmarkVerifySameMessage(b *testing.B) {
+	var message [32]byte
+	rand.Read(message[:])
+	for _, n := range []int{100, 1000} {
+		publicKeys := make([][32]byte, n)
+		signatures := make([][64]byte, n)
+		for i := range publicKeys {
+			var d *big.Int
+			d, publicKeys[i], _ = GenerateKeyPair(nil)
+			signatures[i], _ = Sign(d, message, nil)
+		}
+		b.Run(fmt.Sprintf("%d/Verify", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j := range publicKeys {
+					Verify(publicKeys[j], message, signatures[j])
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%d/VerifySameMessage", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifySameMessage(publicKeys, message, signatures)
+			}
+		})
+	}
+}
+
+func BenchmarkBatchVerifyParallel(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(2000, b)
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BatchVerify(publicKeys, messages, signatures)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BatchVerifyParallel(context.Background(), publicKeys, messages, signatures)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+//go:build js && wasm
+// +build js,wasm
+
+package schnorr
+
+import (
+	"testing"
+)
+
+// These track how long verifying takes in a browser. Run them with
+//
+//	GOOS=js GOARCH=wasm go test -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" -bench WASM
+//
+// which needs node in the PATH. Older versions of Go have go_js_wasm_exec in
+// misc/wasm instead.
+
+func BenchmarkVerifyWASM(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(1, b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Verify(publicKeys[0], messages[0], signatures[0])
+	}
+}
+
+func BenchmarkBatchVerifyWASM(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(100, b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BatchVerify(publicKeys, messages, signatures)
+	}
+}
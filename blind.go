@@ -0,0 +1,185 @@
+package schnorr
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+// Blind signatures let a requester get a signature of a message the signer
+// never sees, and the signer can't later link the signature to the session
+// it came from. The result is an ordinary signature that passes Verify. This
+// is the classic three move protocol:
+//
+//  1. the signer calls GenerateBlindNonce and sends the public nonce,
+//  2. the requester calls NewBlindRequest and sends the blinded challenge,
+//  3. the signer calls BlindSign and sends back the blinded signature, which
+//     the requester turns into a signature with BlindRequest.Unblind.
+//
+// WARNING: a signer that keeps many sessions open at the same time can be
+// made to produce one more signature than it ran sessions, with Wagner's
+// algorithm or, for a few hundred sessions, the ROS attack of Benhamouda et
+// al. which takes seconds. Signers must finish every session before starting
+// the next one, or bound how many can be open at once to a handful.
+// https://eprint.iacr.org/2020/945
+
+// GenerateBlindNonce returns the signer's secret nonce and the public nonce to
+// send to the requester. Calling with a nil random will cause the function to
+// use crypto/rand.
+//
+// WARNING: a secret nonce must be passed to BlindSign only once. Two blinded
+// signatures made with the same nonce give the private key away.
+func GenerateBlindNonce(random io.Reader) (secNonce [32]byte, pubNonce [32]byte, err error) {
+	k, err := randomScalar(random)
+	if err != nil {
+		return secNonce, pubNonce, err
+	}
+	defer zeroInt(k)
+
+	// only the x coordinate is sent, so pick the k for the even y.
+	Rx, Ry := baseMult(k)
+	if Ry.Bit(0) == 1 {
+		k.Sub(Curve.N, k)
+	}
+	intToByteInto(secNonce[:], k)
+	intToByteInto(pubNonce[:], Rx)
+	return secNonce, pubNonce, nil
+}
+
+// BlindRequest is the requester's side of a blind signing session, which
+// holds the blinding factors. It must be kept secret until the signature is
+// made public, as it links the signature to the session.
+type BlindRequest struct {
+	publicKey [32]byte
+	message   [32]byte
+	pubNonce  [32]byte
+	// alpha blinds s, rX is the x coordinate of R + alpha*G + beta*P and
+	// challenge is e + beta, which is what the signer sees.
+	alpha     *big.Int
+	rX        [32]byte
+	challenge *big.Int
+}
+
+// NewBlindRequest blinds the signature of a 32 byte message under the public
+// key, with the public nonce the signer sent, returning the request and the
+// blinded challenge to send to the signer. Calling with a nil random will
+// cause the function to use crypto/rand.
+func NewBlindRequest(random io.Reader, publicKey [32]byte, pubNonce [32]byte, message [32]byte) (*BlindRequest, [32]byte, error) {
+	blindedChallenge := [32]byte{}
+	P, ok := liftX(new(big.Int).SetBytes(publicKey[:]))
+	if !ok {
+		return nil, blindedChallenge, ErrInvalidPublicKey
+	}
+	R, ok := liftX(new(big.Int).SetBytes(pubNonce[:]))
+	if !ok {
+		return nil, blindedChallenge, errors.New("nonce is not a valid point")
+	}
+
+	// the signature needs R' = R + alpha*G + beta*P with an even y, which
+	// takes two tries on average. alpha and beta are what keep the signer
+	// from linking the signature to the session, so they are multiplied in
+	// constant time.
+	for {
+		alpha, err := randomScalar(random)
+		if err != nil {
+			return nil, blindedChallenge, err
+		}
+		beta, err := randomScalar(random)
+		if err != nil {
+			zeroInt(alpha)
+			return nil, blindedChallenge, err
+		}
+
+		Rb := baseMultJacobian(alpha)
+		betaX, betaY := scalarMult(&P, beta)
+		betaP := newAffinePoint(betaX, betaY)
+		Rb.addMixedConst(Rb, &betaP)
+		Rb.addMixedConst(Rb, &R)
+		if Rb.isInfinity() {
+			zeroInt(alpha)
+			zeroInt(beta)
+			continue
+		}
+		Rx, Ry := Rb.affine()
+		if Ry.Bit(0) == 1 {
+			zeroInt(alpha)
+			zeroInt(beta)
+			continue
+		}
+
+		request := &BlindRequest{
+			publicKey: publicKey,
+			message:   message,
+			pubNonce:  pubNonce,
+			alpha:     alpha,
+		}
+		intToByteInto(request.rX[:], Rx)
+		e := challenge(request.rX[:], publicKey[:], message[:])
+		e.Add(e, beta)
+		zeroInt(beta)
+		request.challenge = e.Mod(e, Curve.N)
+		intToByteInto(blindedChallenge[:], request.challenge)
+		return request, blindedChallenge, nil
+	}
+}
+
+// BlindSign answers a blinded challenge with the private key and the secret
+// nonce of the session, returning the blinded signature.
+func BlindSign(privateKey *big.Int, secNonce [32]byte, blindedChallenge [32]byte) ([32]byte, error) {
+	blindSig := [32]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return blindSig, ErrKeyOutOfRange
+	}
+	k, err := ParseScalar(secNonce[:])
+	if err != nil {
+		return blindSig, ErrNonceOutOfRange
+	}
+	defer zeroInt(k)
+	e := new(big.Int).SetBytes(blindedChallenge[:])
+	if e.Cmp(Curve.N) >= 0 {
+		return blindSig, errors.New("the blinded challenge must be below n")
+	}
+
+	// the public key was lifted with an even y, so do the same as Sign does
+	// with d.
+	_, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	defer zeroInt(d)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+
+	// k + e*d
+	e.Mul(e, d)
+	e.Add(e, k)
+	intToByteInto(blindSig[:], e.Mod(e, Curve.N))
+	zeroInt(e)
+	return blindSig, nil
+}
+
+// Unblind checks the blinded signature the signer sent and turns it into a 64
+// byte signature of the message that verifies against the public key. It
+// clears the blinding factor once it succeeds, so it can only succeed once.
+func (b *BlindRequest) Unblind(blindSig [32]byte) ([64]byte, error) {
+	sig := [64]byte{}
+	s, err := ParseScalar(blindSig[:])
+	if err != nil {
+		return sig, err
+	}
+
+	// sG = R + eP, or the signer didn't answer the challenge it was sent.
+	var r [32]byte
+	copy(r[:], b.pubNonce[:])
+	if ok, _ := VerifyWithChallenge(b.publicKey, r, s, b.challenge); !ok {
+		return sig, ErrVerifyFailed
+	}
+	if b.alpha.Sign() == 0 {
+		return sig, errors.New("the request was already unblinded")
+	}
+
+	s.Add(s, b.alpha)
+	zeroInt(b.alpha)
+	copy(sig[:32], b.rX[:])
+	intToByteInto(sig[32:], s.Mod(s, Curve.N))
+	return sig, nil
+}
@@ -0,0 +1,56 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestBlindSignatures(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "the signer never sees this")
+
+	// a few rounds so both parities of the public key and of R' come up.
+	for i := 0; i < 8; i++ {
+		d, publicKey, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+
+		secNonce, pubNonce, err := GenerateBlindNonce(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateBlindNonce: %v", err)
+		}
+		request, blindedChallenge, err := NewBlindRequest(nil, publicKey, pubNonce, message)
+		if err != nil {
+			t.Fatalf("Unexpected error from NewBlindRequest: %v", err)
+		}
+		blindSig, err := BlindSign(d, secNonce, blindedChallenge)
+		if err != nil {
+			t.Fatalf("Unexpected error from BlindSign: %v", err)
+		}
+		signature, err := request.Unblind(blindSig)
+		if err != nil {
+			t.Fatalf("Unexpected error from Unblind: %v", err)
+		}
+		if ok, err := Verify(publicKey, message, signature); !ok {
+			t.Fatalf("Verify of an unblinded signature failed: %v", err)
+		}
+
+		// nothing the signer saw is in the signature.
+		if string(signature[:32]) == string(pubNonce[:]) || string(signature[32:]) == string(blindSig[:]) {
+			t.Fatalf("Unblind returned a signature the signer can link to the session")
+		}
+
+		// a blinded signature for another challenge is rejected.
+		other := blindedChallenge
+		other[31] ^= 1
+		wrong, _ := BlindSign(d, secNonce, other)
+		if _, err := request.Unblind(wrong); err != ErrVerifyFailed {
+			t.Fatalf("Unblind(wrong signature) = %v, want %v", err, ErrVerifyFailed)
+		}
+
+		// the blinding factor is gone once the signature is unblinded.
+		if _, err := request.Unblind(blindSig); err == nil {
+			t.Fatalf("Unblind succeeded twice")
+		}
+	}
+}
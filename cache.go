@@ -0,0 +1,73 @@
+package schnorr
+
+import (
+	"container/list"
+	"sync"
+)
+
+// PublicKeyCache verifies signatures like Verify, but remembers the points of
+// the last public keys it lifted from their x coordinate, for callers that see
+// the same keys over and over. Invalid keys are not remembered.
+//
+// It is safe to use from many goroutines at once.
+type PublicKeyCache struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List // of publicKeyCacheEntry, most recent first
+	keys  map[[32]byte]*list.Element
+}
+
+type publicKeyCacheEntry struct {
+	publicKey [32]byte
+	point     affinePoint
+}
+
+// NewPublicKeyCache returns a PublicKeyCache that remembers the last size
+// public keys.
+func NewPublicKeyCache(size int) *PublicKeyCache {
+	return &PublicKeyCache{
+		size:  size,
+		order: list.New(),
+		keys:  make(map[[32]byte]*list.Element, size),
+	}
+}
+
+// Verify a 64 byte signature of a 32 byte message against the public key, see
+// Verify.
+func (c *PublicKeyCache) Verify(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, error) {
+	P, ok := c.lift(publicKey)
+	if !ok {
+		return false, ValidatePublicKey(publicKey[:])
+	}
+	return verify(&P, message, signature, challenge)
+}
+
+func (c *PublicKeyCache) lift(publicKey [32]byte) (affinePoint, bool) {
+	c.mu.Lock()
+	if e, ok := c.keys[publicKey]; ok {
+		c.order.MoveToFront(e)
+		P := e.Value.(publicKeyCacheEntry).point
+		c.mu.Unlock()
+		return P, true
+	}
+	c.mu.Unlock()
+
+	// lifted without holding the lock, another goroutine may be doing the same
+	// key at the same time, which is harmless.
+	P, ok := liftPublicKey(publicKey)
+	if !ok {
+		return P, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[publicKey]; !ok {
+		c.keys[publicKey] = c.order.PushFront(publicKeyCacheEntry{publicKey: publicKey, point: P})
+		if c.order.Len() > c.size {
+			oldest := c.order.Remove(c.order.Back()).(publicKeyCacheEntry)
+			delete(c.keys, oldest.publicKey)
+		}
+	}
+	return P, true
+}
@@ -0,0 +1,85 @@
+package schnorr
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPublicKeyCache(t *testing.T) {
+	publicKeys, messages, signatures := makeBatch(4, t)
+	c := NewPublicKeyCache(2)
+
+	wg := sync.WaitGroup{}
+	for round := 0; round < 4; round++ {
+		for i := range signatures {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if ok, err := c.Verify(publicKeys[i], messages[i], signatures[i]); !ok {
+					t.Errorf("PublicKeyCache.Verify of signature %d failed: %v", i, err)
+				}
+				if ok, _ := c.Verify(publicKeys[i], messages[(i+1)%len(messages)], signatures[i]); ok {
+					t.Errorf("PublicKeyCache.Verify of signature %d succeeded for another message", i)
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	if c.order.Len() != 2 || len(c.keys) != 2 {
+		t.Fatalf("PublicKeyCache holds %d keys in its list and %d in its map, want 2", c.order.Len(), len(c.keys))
+	}
+
+	// the most recent keys are kept.
+	c.Verify(publicKeys[0], messages[0], signatures[0])
+	c.Verify(publicKeys[1], messages[1], signatures[1])
+	if _, ok := c.keys[publicKeys[0]]; !ok {
+		t.Fatalf("PublicKeyCache dropped a recent key")
+	}
+	if _, ok := c.keys[publicKeys[2]]; ok {
+		t.Fatalf("PublicKeyCache kept an old key")
+	}
+
+	var notOnCurve [32]byte
+	notOnCurve[31] = 5
+	if ok, err := c.Verify(notOnCurve, messages[0], signatures[0]); ok || err != ErrInvalidPublicKey {
+		t.Fatalf("PublicKeyCache.Verify with a key off the curve = %v, %v, want false, %v", ok, err, ErrInvalidPublicKey)
+	}
+	if _, ok := c.keys[notOnCurve]; ok {
+		t.Fatalf("PublicKeyCache kept an invalid key")
+	}
+}
+
+func BenchmarkPublicKeyCache(b *testing.B) {
+	publicKeys, messages, signatures := makeBatch(10, b)
+	b.Run("Verify", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			j := i % len(signatures)
+			Verify(publicKeys[j], messages[j], signatures[j])
+		}
+	})
+	b.Run("PublicKeyCache", func(b *testing.B) {
+		c := NewPublicKeyCache(len(publicKeys))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			j := i % len(signatures)
+			c.Verify(publicKeys[j], messages[j], signatures[j])
+		}
+	})
+	b.Run("Unmarshal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Unmarshal(Curve, publicKeys[i%len(publicKeys)][:])
+		}
+	})
+	b.Run("lift", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			liftPublicKey(publicKeys[i%len(publicKeys)])
+		}
+	})
+	b.Run("cached lift", func(b *testing.B) {
+		c := NewPublicKeyCache(len(publicKeys))
+		for i := 0; i < b.N; i++ {
+			c.lift(publicKeys[i%len(publicKeys)])
+		}
+	})
+}
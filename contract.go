@@ -0,0 +1,92 @@
+package schnorr
+
+import (
+	"math/big"
+)
+
+// Sign-to-contract commits to some data inside the nonce of a signature: the
+// signer picks a nonce point R0 = k0*G and signs with R = R0 + t*G, where
+// t = hash(R0 || commitment). The result is an ordinary signature, but whoever
+// is given R0 and the data can check that the signature commits to it, which
+// is how signatures double as timestamps.
+
+// SignWithCommitment signs a 32 byte message with the private key, committing
+// to commitment. It returns the signature and R0 in compressed form, which is
+// what VerifyCommitment needs besides the commitment.
+func SignWithCommitment(privateKey *big.Int, message [32]byte, commitment []byte) ([64]byte, [33]byte, error) {
+	sig := [64]byte{}
+	nonce := [33]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return sig, nonce, ErrKeyOutOfRange
+	}
+
+	Px, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	defer zeroInt(d)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+
+	// the nonce must depend on the commitment: t is public, so two signatures
+	// of the same message with the same k0 would give the private key away.
+	commitmentHash := taggedHash("schnorr/s2c/data", commitment)
+	k := hashToScalar(taggedHash("schnorr/s2c/nonce", intToByte(d), commitmentHash[:], intToByte(Px), message[:]))
+	defer zeroInt(k)
+	if k.Sign() == 0 {
+		return sig, nonce, ErrZeroNonce
+	}
+
+	R0x, R0y := baseMult(k)
+	R0 := newAffinePoint(R0x, R0y)
+	var R0j jacobianPoint
+	R0j.setAffine(&R0)
+	copy(nonce[:], compressPoint(&R0j))
+
+	k.Add(k, contractTweak(nonce, commitment))
+	k.Mod(k, Curve.N)
+	if k.Sign() == 0 {
+		return sig, nonce, ErrZeroNonce
+	}
+	Rx, Ry := baseMult(k)
+	if Ry.Bit(0) == 1 {
+		k.Sub(Curve.N, k)
+	}
+
+	rX := intToByte(Rx)
+	e := getE(Px, Py, rX, message)
+	e.Mul(e, d)
+	e.Add(e, k)
+	e.Mod(e, Curve.N)
+
+	copy(sig[:32], rX)
+	intToByteInto(sig[32:], e)
+	return sig, nonce, nil
+}
+
+// VerifyCommitment tells whether the nonce of signature is R0 tweaked with
+// commitment, given R0 in compressed form as returned by SignWithCommitment.
+// It doesn't check the signature itself, Verify does that.
+func VerifyCommitment(signature [64]byte, originalNonce [33]byte, commitment []byte) bool {
+	R0, ok := decompressPoint(originalNonce[:])
+	if !ok || R0.isInfinity() {
+		return false
+	}
+
+	tGx, tGy := baseMult(contractTweak(originalNonce, commitment))
+	tG := newAffinePoint(tGx, tGy)
+	var R jacobianPoint
+	R.addMixed(&R0, &tG)
+	if R.isInfinity() {
+		return false
+	}
+
+	// the signer negates k when R has an odd y, which doesn't change the x.
+	Rx, _ := R.affine()
+	return string(intToByte(Rx)) == string(signature[:32])
+}
+
+// contractTweak returns t = hash(R0 || commitment) mod n.
+func contractTweak(originalNonce [33]byte, commitment []byte) *big.Int {
+	commitmentHash := taggedHash("schnorr/s2c/data", commitment)
+	return hashToScalar(taggedHash("schnorr/s2c/tweak", originalNonce[:], commitmentHash[:]))
+}
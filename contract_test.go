@@ -0,0 +1,49 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestSignWithCommitment(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "the commitment is in the nonce")
+
+	// a few rounds so both parities of R0 and of R come up.
+	for i := 0; i < 8; i++ {
+		d, publicKey, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		commitment := []byte("some document")
+
+		signature, nonce, err := SignWithCommitment(d, message, commitment)
+		if err != nil {
+			t.Fatalf("Unexpected error from SignWithCommitment: %v", err)
+		}
+		if ok, err := Verify(publicKey, message, signature); !ok {
+			t.Fatalf("Verify of a signature with a commitment failed: %v", err)
+		}
+		if !VerifyCommitment(signature, nonce, commitment) {
+			t.Fatalf("VerifyCommitment(%x, %x, %q) = false, want true", signature, nonce, commitment)
+		}
+		if VerifyCommitment(signature, nonce, []byte("another document")) {
+			t.Fatalf("VerifyCommitment succeeded for the wrong commitment")
+		}
+		other := nonce
+		other[0] ^= 1
+		if VerifyCommitment(signature, other, commitment) {
+			t.Fatalf("VerifyCommitment succeeded for the wrong nonce")
+		}
+
+		// the nonce depends on the commitment, or the key could be computed
+		// from two signatures of the same message.
+		if signature2, nonce2, _ := SignWithCommitment(d, message, []byte("another document")); nonce2 == nonce || signature2 == signature {
+			t.Fatalf("SignWithCommitment used the same nonce for different commitments")
+		}
+	}
+
+	var zero [33]byte
+	if VerifyCommitment([64]byte{}, zero, nil) {
+		t.Fatalf("VerifyCommitment succeeded for the point at infinity")
+	}
+}
@@ -0,0 +1,86 @@
+package schnorr
+
+import (
+	"math/big"
+)
+
+// A DLEQ proof shows that A = x*G and B = x*G2 for the same secret x without
+// revealing it, which is what cross-chain swaps use to tie a key on one side to
+// a key on the other. It is a Schnorr proof on both bases at once: the prover
+// commits to R1 = k*G and R2 = k*G2, e = hash(A || B || G2 || R1 || R2) and
+// s = k + e*x. Points are 33 byte compressed, since unlike the public keys of
+// signatures their y matters.
+
+// ProveDLEQ returns a 64 byte proof e || s that A = secret*G and B = secret*G2
+// share the discrete log, along with A and B, for a second base G2 in
+// compressed form. The nonce is derived from the secret and G2, which fix
+// everything that goes into the challenge.
+func ProveDLEQ(secret *big.Int, G2 [33]byte) (proof [64]byte, A [33]byte, B [33]byte, err error) {
+	if secret.Cmp(One) < 0 || secret.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return proof, A, B, ErrKeyOutOfRange
+	}
+	H, ok := decompressAffine(G2[:])
+	if !ok {
+		return proof, A, B, ErrInvalidPublicKey
+	}
+
+	// both bases are multiplied in constant time, as the secret and the
+	// nonce are involved.
+	x := intToByte(secret)
+	defer zeroBytes(x)
+	copy(A[:], compressPoint(baseMultJacobian(secret)))
+	copy(B[:], compressAffineInts(scalarMult(&H, secret)))
+
+	nonceHash := taggedHash("schnorr/dleq/nonce", x, G2[:])
+	k := hashToScalar(nonceHash)
+	zeroBytes(nonceHash[:])
+	defer zeroInt(k)
+	if k.Sign() == 0 {
+		return proof, A, B, ErrZeroNonce
+	}
+	R1 := compressPoint(baseMultJacobian(k))
+	R2 := compressAffineInts(scalarMult(&H, k))
+
+	e := dleqChallenge(A, B, G2, R1, R2)
+	s := new(big.Int).Mul(e, secret)
+	s.Add(s, k)
+	intToByteInto(proof[:32], e)
+	intToByteInto(proof[32:], s.Mod(s, Curve.N))
+	zeroInt(s)
+	return proof, A, B, nil
+}
+
+// VerifyDLEQ checks a proof from ProveDLEQ that A and B, in compressed form,
+// have the same discrete log to the bases G and G2.
+func VerifyDLEQ(A, B, G2 [33]byte, proof [64]byte) bool {
+	H, okH := decompressAffine(G2[:])
+	Ap, okA := decompressAffine(A[:])
+	Bp, okB := decompressAffine(B[:])
+	if !okH || !okA || !okB {
+		return false
+	}
+	e := new(big.Int).SetBytes(proof[:32])
+	s := new(big.Int).SetBytes(proof[32:])
+	if e.Cmp(Curve.N) >= 0 || s.Cmp(Curve.N) >= 0 {
+		return false
+	}
+
+	// R1 = sG - eA and R2 = sG2 - eB
+	negE := new(big.Int).Sub(Curve.N, e)
+	R1 := multiScalarMult([]affinePoint{newAffinePoint(Curve.Gx, Curve.Gy), Ap}, []*big.Int{s, negE})
+	R2 := multiScalarMult([]affinePoint{H, Bp}, []*big.Int{s, negE})
+	if R1.isInfinity() || R2.isInfinity() {
+		return false
+	}
+	return dleqChallenge(A, B, G2, compressPoint(&R1), compressPoint(&R2)).Cmp(e) == 0
+}
+
+func dleqChallenge(A, B, G2 [33]byte, R1, R2 []byte) *big.Int {
+	return hashToScalar(taggedHash("schnorr/dleq", A[:], B[:], G2[:], R1, R2))
+}
+
+// compressAffineInts is compressPoint for a point given as x, y.
+func compressAffineInts(x, y *big.Int) []byte {
+	p := newAffinePoint(x, y)
+	return compressPoint(new(jacobianPoint).setAffine(&p))
+}
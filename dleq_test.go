@@ -0,0 +1,53 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestDLEQ(t *testing.T) {
+	h, _, _ := GenerateKeyPair(nil)
+	var G2 [33]byte
+	copy(G2[:], compressPoint(baseMultJacobian(h)))
+
+	for i := 0; i < 4; i++ {
+		secret, _, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		proof, A, B, err := ProveDLEQ(secret, G2)
+		if err != nil {
+			t.Fatalf("Unexpected error from ProveDLEQ: %v", err)
+		}
+		if !VerifyDLEQ(A, B, G2, proof) {
+			t.Fatalf("VerifyDLEQ(%x, %x, %x, %x) = false, want true", A, B, G2, proof)
+		}
+		var expectedA [33]byte
+		copy(expectedA[:], compressPoint(baseMultJacobian(secret)))
+		if A != expectedA {
+			t.Fatalf("ProveDLEQ A = %x, want %x", A, expectedA)
+		}
+
+		// B for another secret.
+		other, _, _ := GenerateKeyPair(nil)
+		_, _, otherB, _ := ProveDLEQ(other, G2)
+		if VerifyDLEQ(A, otherB, G2, proof) {
+			t.Fatalf("VerifyDLEQ succeeded for a B with another discrete log")
+		}
+		if VerifyDLEQ(B, A, G2, proof) {
+			t.Fatalf("VerifyDLEQ succeeded with A and B swapped")
+		}
+		bad := proof
+		bad[63] ^= 1
+		if VerifyDLEQ(A, B, G2, bad) {
+			t.Fatalf("VerifyDLEQ succeeded for a changed proof")
+		}
+	}
+
+	secret, _, _ := GenerateKeyPair(nil)
+	if _, _, _, err := ProveDLEQ(secret, [33]byte{}); err == nil {
+		t.Fatalf("ProveDLEQ with G2 at infinity succeeded")
+	}
+	if _, _, _, err := ProveDLEQ(Zero, G2); err != ErrKeyOutOfRange {
+		t.Fatalf("ProveDLEQ(0) = %v, want %v", err, ErrKeyOutOfRange)
+	}
+}
@@ -0,0 +1,33 @@
+package schnorr
+
+import (
+	"math/big"
+)
+
+// ECDH computes a shared secret between the private key and someone else's 32
+// byte public key. The secret is the 32 byte x coordinate of
+// privateKey * publicKey, not hashed, which is what Nostr uses for encrypted
+// direct messages. Hash it before using it as a symmetric key elsewhere.
+//
+// Since only x is returned, it doesn't matter that the public key doesn't
+// carry the parity of y.
+//
+// The multiplication by the private key runs in constant time, whatever point
+// the public key is, but decoding the public key and converting the private
+// key from a big.Int don't.
+func ECDH(privateKey *big.Int, publicKey [32]byte) ([32]byte, error) {
+	secret := [32]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return secret, ErrKeyOutOfRange
+	}
+
+	Px, Py := Unmarshal(Curve, publicKey[:])
+	if Px == nil || Py == nil || !Curve.IsOnCurve(Px, Py) {
+		return secret, ErrInvalidPublicKey
+	}
+
+	P := newAffinePoint(Px, Py)
+	Sx, _ := scalarMult(&P, privateKey)
+	intToByteInto(secret[:], Sx)
+	return secret, nil
+}
@@ -0,0 +1,32 @@
+package schnorr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestECDH(t *testing.T) {
+	a, A, _ := GenerateKeyPair(nil)
+	b, B, _ := GenerateKeyPair(nil)
+
+	ab, err := ECDH(a, B)
+	if err != nil {
+		t.Fatalf("Unexpected error from ECDH: %v", err)
+	}
+	ba, err := ECDH(b, A)
+	if err != nil {
+		t.Fatalf("Unexpected error from ECDH: %v", err)
+	}
+	if ab != ba {
+		t.Fatalf("ECDH secrets don't match: %x != %x", ab, ba)
+	}
+
+	var bad [32]byte
+	copy(bad[:], intToByte(Curve.P))
+	if _, err := ECDH(a, bad); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("ECDH with an invalid public key = %v, want ErrInvalidPublicKey", err)
+	}
+	if _, err := ECDH(Zero, B); !errors.Is(err, ErrKeyOutOfRange) {
+		t.Fatalf("ECDH with a zero private key = %v, want ErrKeyOutOfRange", err)
+	}
+}
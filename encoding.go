@@ -0,0 +1,202 @@
+package schnorr
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParsePublicKeyHex decodes a 32 byte public key from hex.
+func ParsePublicKeyHex(s string) ([32]byte, error) {
+	pk := [32]byte{}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return pk, err
+	}
+	if len(b) != 32 {
+		return pk, fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(b))
+	}
+	copy(pk[:], b)
+	return pk, nil
+}
+
+// PublicKeyToHex encodes a public key as lowercase hex.
+func PublicKeyToHex(publicKey [32]byte) string {
+	return hex.EncodeToString(publicKey[:])
+}
+
+// ParseSignatureHex decodes a 64 byte signature from hex.
+func ParseSignatureHex(s string) ([64]byte, error) {
+	sig := [64]byte{}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return sig, err
+	}
+	if len(b) != 64 {
+		return sig, fmt.Errorf("%w, not %d", ErrBadSignatureLength, len(b))
+	}
+	copy(sig[:], b)
+	return sig, nil
+}
+
+// SignatureToHex encodes a signature as lowercase hex.
+func SignatureToHex(signature [64]byte) string {
+	return hex.EncodeToString(signature[:])
+}
+
+// VerifyNostr verifies a Nostr event signature given the pubkey, id and sig
+// fields of the event as they appear in its JSON, all hex. The id is the
+// message, it has to be checked to be the hash of the serialized event
+// separately. Errors in parsing a field say which field it was.
+func VerifyNostr(pubkeyHex, idHex, sigHex string) (bool, error) {
+	publicKey, err := ParsePublicKeyHex(pubkeyHex)
+	if err != nil {
+		return false, fmt.Errorf("pubkey: %w", err)
+	}
+	id := [32]byte{}
+	b, err := hex.DecodeString(idHex)
+	if err != nil {
+		return false, fmt.Errorf("id: %w", err)
+	}
+	if len(b) != 32 {
+		return false, fmt.Errorf("id: %w, not %d", ErrBadMessageLength, len(b))
+	}
+	copy(id[:], b)
+	signature, err := ParseSignatureHex(sigHex)
+	if err != nil {
+		return false, fmt.Errorf("sig: %w", err)
+	}
+	return Verify(publicKey, id, signature)
+}
+
+// ParsePublicKeyBase64 decodes a 32 byte public key from base64, in either
+// the standard or the URL-safe alphabet, with or without padding.
+func ParsePublicKeyBase64(s string) ([32]byte, error) {
+	pk := [32]byte{}
+	b, err := decodeBase64(s)
+	if err != nil {
+		return pk, err
+	}
+	if len(b) != 32 {
+		return pk, fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(b))
+	}
+	copy(pk[:], b)
+	return pk, nil
+}
+
+// PublicKeyToBase64 encodes a public key as padded standard base64.
+func PublicKeyToBase64(publicKey [32]byte) string {
+	return base64.StdEncoding.EncodeToString(publicKey[:])
+}
+
+// ParseSignatureBase64 decodes a 64 byte signature from base64, in either the
+// standard or the URL-safe alphabet, with or without padding.
+func ParseSignatureBase64(s string) ([64]byte, error) {
+	sig := [64]byte{}
+	b, err := decodeBase64(s)
+	if err != nil {
+		return sig, err
+	}
+	if len(b) != 64 {
+		return sig, fmt.Errorf("%w, not %d", ErrBadSignatureLength, len(b))
+	}
+	copy(sig[:], b)
+	return sig, nil
+}
+
+// SignatureToBase64 encodes a signature as padded standard base64.
+func SignatureToBase64(signature [64]byte) string {
+	return base64.StdEncoding.EncodeToString(signature[:])
+}
+
+// decodeBase64 tells the two alphabets apart by the characters only one of
+// them has, a string with neither decodes the same under both. Strings with
+// padding must have exactly the padding the encoding calls for, and the bits
+// left over in the last character must be zero, so that every key and
+// signature has only one encoding in each form.
+func decodeBase64(s string) ([]byte, error) {
+	url := strings.ContainsAny(s, "-_")
+	var encoding *base64.Encoding
+	switch {
+	case strings.Contains(s, "=") && url:
+		encoding = base64.URLEncoding
+	case strings.Contains(s, "="):
+		encoding = base64.StdEncoding
+	case url:
+		encoding = base64.RawURLEncoding
+	default:
+		encoding = base64.RawStdEncoding
+	}
+	b, err := encoding.Strict().DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	return b, nil
+}
+
+// MarshalJSON encodes the public key as a 32 byte hex string, or as null if it
+// has no x, as in the zero PublicKey.
+func (p PublicKey) MarshalJSON() ([]byte, error) {
+	if p.X == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(PublicKeyToHex(p.Serialize()))
+}
+
+// UnmarshalJSON decodes a public key from a 32 byte hex string, checking that
+// it is a valid point. The hex string only holds x, so the key it gives back
+// is always the one with the even y, like ParsePublicKey. A key with an odd y
+// comes back with the same Serialize but is not Equal to it. A null leaves p
+// as it is.
+func (p *PublicKey) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("public key must be a hex string: %w", err)
+	}
+	pk, err := ParsePublicKeyHex(s)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParsePublicKey(pk[:])
+	if err != nil {
+		return err
+	}
+	*p = *parsed
+	return nil
+}
+
+// MarshalJSON encodes the signature as a 64 byte hex string, or as null if it
+// is missing r or s, as in the zero Signature.
+func (sig Signature) MarshalJSON() ([]byte, error) {
+	if sig.R == nil || sig.S == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(SignatureToHex(sig.Serialize()))
+}
+
+// UnmarshalJSON decodes a signature from a 64 byte hex string, checking that r
+// and s are in range. A null leaves sig as it is.
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("signature must be a hex string: %w", err)
+	}
+	b, err := ParseSignatureHex(s)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseSignature(b[:])
+	if err != nil {
+		return err
+	}
+	*sig = *parsed
+	return nil
+}
@@ -0,0 +1,203 @@
+package schnorr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHex(t *testing.T) {
+	pkHex := "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"
+	sigHex := "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A"
+
+	pk, err := ParsePublicKeyHex(pkHex)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParsePublicKeyHex: %v", err)
+	}
+	if observed := PublicKeyToHex(pk); observed != strings.ToLower(pkHex) {
+		t.Fatalf("PublicKeyToHex = %s, want %s", observed, strings.ToLower(pkHex))
+	}
+	sig, err := ParseSignatureHex(sigHex)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseSignatureHex: %v", err)
+	}
+	if observed := SignatureToHex(sig); observed != strings.ToLower(sigHex) {
+		t.Fatalf("SignatureToHex = %s, want %s", observed, strings.ToLower(sigHex))
+	}
+
+	if _, err := ParsePublicKeyHex(pkHex[2:]); !errors.Is(err, ErrBadPublicKeyLength) {
+		t.Fatalf("ParsePublicKeyHex of 31 bytes = %v, want ErrBadPublicKeyLength", err)
+	}
+	if _, err := ParseSignatureHex(sigHex + "00"); !errors.Is(err, ErrBadSignatureLength) {
+		t.Fatalf("ParseSignatureHex of 65 bytes = %v, want ErrBadSignatureLength", err)
+	}
+	if _, err := ParsePublicKeyHex("zz" + pkHex[2:]); err == nil {
+		t.Fatalf("ParsePublicKeyHex of invalid hex should have failed")
+	}
+}
+
+func TestVerifyNostr(t *testing.T) {
+	pubkey := "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659"
+	id := "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89"
+	sig := "6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a"
+
+	if ok, err := VerifyNostr(pubkey, id, sig); !ok {
+		t.Fatalf("VerifyNostr of a valid event failed: %v", err)
+	}
+	otherID := "143f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89"
+	if ok, err := VerifyNostr(pubkey, otherID, sig); ok || err != ErrVerifyFailed {
+		t.Fatalf("VerifyNostr with another id = %v, %v, want false, %v", ok, err, ErrVerifyFailed)
+	}
+
+	tests := []struct {
+		name            string
+		pubkey, id, sig string
+		want            error
+		prefix          string
+	}{
+		{"short pubkey", pubkey[2:], id, sig, ErrBadPublicKeyLength, "pubkey: "},
+		{"short id", pubkey, id[2:], sig, ErrBadMessageLength, "id: "},
+		{"long sig", pubkey, id, sig + "00", ErrBadSignatureLength, "sig: "},
+		{"bad hex id", pubkey, "zz" + id[2:], sig, nil, "id: "},
+	}
+	for _, test := range tests {
+		_, err := VerifyNostr(test.pubkey, test.id, test.sig)
+		if err == nil || !strings.HasPrefix(err.Error(), test.prefix) {
+			t.Fatalf("VerifyNostr(%s) = %v, want an error starting with %q", test.name, err, test.prefix)
+		}
+		if test.want != nil && !errors.Is(err, test.want) {
+			t.Fatalf("VerifyNostr(%s) = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type event struct {
+		PublicKey PublicKey  `json:"pubkey"`
+		Signature *Signature `json:"sig"`
+	}
+
+	var message [32]byte
+	d, publicKey, _ := GenerateKeyPair(nil)
+	signature, _ := Sign(d, message, nil)
+	pub, _ := ParsePublicKey(publicKey[:])
+	sig, _ := ParseSignature(signature[:])
+
+	data, err := json.Marshal(event{*pub, sig})
+	if err != nil {
+		t.Fatalf("Unexpected error from json.Marshal: %v", err)
+	}
+	expected := `{"pubkey":"` + PublicKeyToHex(publicKey) + `","sig":"` + SignatureToHex(signature) + `"}`
+	if string(data) != expected {
+		t.Fatalf("json.Marshal = %s, want %s", data, expected)
+	}
+
+	var decoded event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error from json.Unmarshal: %v", err)
+	}
+	if decoded.PublicKey.Serialize() != publicKey || decoded.Signature.Serialize() != signature {
+		t.Fatalf("json round trip = %+v, want %+v", decoded, event{*pub, sig})
+	}
+
+	for _, bad := range []string{
+		`{"pubkey":"zz"}`,
+		`{"pubkey":1}`,
+		`{"pubkey":"` + PublicKeyToHex(publicKey)[2:] + `"}`,
+		`{"pubkey":"` + strings.Repeat("f", 64) + `"}`,
+		`{"sig":"` + SignatureToHex(signature)[2:] + `"}`,
+		`{"sig":"` + strings.Repeat("f", 128) + `"}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &decoded); err == nil {
+			t.Fatalf("json.Unmarshal(%s) should have failed", bad)
+		}
+	}
+}
+
+func TestJSONZeroValues(t *testing.T) {
+	type event struct {
+		PublicKey PublicKey `json:"pubkey"`
+		Signature Signature `json:"sig"`
+	}
+
+	data, err := json.Marshal(event{})
+	if err != nil {
+		t.Fatalf("Unexpected error from json.Marshal: %v", err)
+	}
+	if expected := `{"pubkey":null,"sig":null}`; string(data) != expected {
+		t.Fatalf("json.Marshal(event{}) = %s, want %s", data, expected)
+	}
+	var decoded event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error from json.Unmarshal: %v", err)
+	}
+	if decoded.PublicKey.X != nil || decoded.Signature.R != nil {
+		t.Fatalf("json.Unmarshal(%s) = %+v, want the zero event", data, decoded)
+	}
+}
+
+func TestJSONOddY(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	public := (&PrivateKey{D: d}).Public().(*PublicKey)
+	if public.HasEvenY() {
+		public, _ = NegatePublicKey(public)
+	}
+
+	data, err := json.Marshal(public)
+	if err != nil {
+		t.Fatalf("Unexpected error from json.Marshal: %v", err)
+	}
+	var decoded PublicKey
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error from json.Unmarshal: %v", err)
+	}
+	if decoded.Serialize() != public.Serialize() || !decoded.HasEvenY() {
+		t.Fatalf("json round trip of a key with an odd y = %x, want %x with an even y", decoded.Serialize(), public.Serialize())
+	}
+}
+
+func TestBase64(t *testing.T) {
+	pk, _ := ParsePublicKeyHex("DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	sig, _ := ParseSignatureHex("6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A")
+
+	encodings := []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding}
+	for _, encoding := range encodings {
+		if observed, err := ParsePublicKeyBase64(encoding.EncodeToString(pk[:])); err != nil || observed != pk {
+			t.Fatalf("ParsePublicKeyBase64(%s) = %x, %v, want %x", encoding.EncodeToString(pk[:]), observed, err, pk)
+		}
+		if observed, err := ParseSignatureBase64(encoding.EncodeToString(sig[:])); err != nil || observed != sig {
+			t.Fatalf("ParseSignatureBase64(%s) = %x, %v, want %x", encoding.EncodeToString(sig[:]), observed, err, sig)
+		}
+	}
+
+	if observed, _ := ParsePublicKeyBase64(PublicKeyToBase64(pk)); observed != pk {
+		t.Fatalf("ParsePublicKeyBase64(PublicKeyToBase64(%x)) = %x", pk, observed)
+	}
+	if observed, _ := ParseSignatureBase64(SignatureToBase64(sig)); observed != sig {
+		t.Fatalf("ParseSignatureBase64(SignatureToBase64(%x)) = %x", sig, observed)
+	}
+
+	if _, err := ParsePublicKeyBase64(base64.StdEncoding.EncodeToString(pk[:31])); !errors.Is(err, ErrBadPublicKeyLength) {
+		t.Fatalf("ParsePublicKeyBase64 of 31 bytes = %v, want ErrBadPublicKeyLength", err)
+	}
+	if _, err := ParseSignatureBase64(base64.StdEncoding.EncodeToString(append(sig[:], 0))); !errors.Is(err, ErrBadSignatureLength) {
+		t.Fatalf("ParseSignatureBase64 of 65 bytes = %v, want ErrBadSignatureLength", err)
+	}
+	// one string for each form.
+	padded := PublicKeyToBase64(pk)
+	raw := strings.TrimRight(padded, "=")
+	lastBits := raw[:len(raw)-1] + string(raw[len(raw)-1]+1)
+	for _, bad := range []string{padded + "=", padded + "==", raw[:10] + "=" + raw[10:], lastBits} {
+		if _, err := ParsePublicKeyBase64(bad); err == nil {
+			t.Fatalf("ParsePublicKeyBase64(%q) should have failed", bad)
+		}
+	}
+
+	for _, bad := range []string{"not base64!", "AB+_", PublicKeyToBase64(pk)[1:]} {
+		if _, err := ParsePublicKeyBase64(bad); err == nil {
+			t.Fatalf("ParsePublicKeyBase64(%q) should have failed", bad)
+		}
+	}
+}
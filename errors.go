@@ -0,0 +1,46 @@
+package schnorr
+
+import (
+	"errors"
+)
+
+var (
+	// ErrBadMessageLength is returned when a message or digest is not 32 bytes.
+	ErrBadMessageLength = errors.New("message must be 32 bytes")
+	// ErrBadAuxLength is returned when aux is given but is not 32 bytes.
+	ErrBadAuxLength = errors.New("aux must be 32 bytes")
+	// ErrKeyOutOfRange is returned for private keys outside 1..n-1.
+	ErrKeyOutOfRange = errors.New("the private key must be an integer in the range 1..n-1")
+	// ErrNonceOutOfRange is returned for caller supplied nonces outside 1..n-1.
+	ErrNonceOutOfRange = errors.New("the nonce must be an integer in the range 1..n-1")
+	// ErrBadScalarLength is returned when a scalar is not 32 bytes.
+	ErrBadScalarLength = errors.New("scalar must be 32 bytes")
+	// ErrScalarOutOfRange is returned for scalars outside 1..n-1.
+	ErrScalarOutOfRange = errors.New("the scalar must be an integer in the range 1..n-1")
+	// ErrBadPublicKeyLength is returned when a public key is not 32 bytes.
+	ErrBadPublicKeyLength = errors.New("public key must be 32 bytes")
+	// ErrInvalidPublicKey is returned when a public key is not a point on the curve.
+	ErrInvalidPublicKey = errors.New("public key is not a valid point")
+	// ErrBadSignatureLength is returned when a signature is not 64 bytes.
+	ErrBadSignatureLength = errors.New("signature must be 64 bytes")
+	// ErrRTooLarge is returned when the r half of a signature is not below p.
+	ErrRTooLarge = errors.New("r is larger than or equal to field size")
+	// ErrSTooLarge is returned when the s half of a signature is not below n.
+	ErrSTooLarge = errors.New("s is larger than or equal to curve order")
+	// ErrZeroNonce is returned in the very unlikely case the nonce is zero.
+	ErrZeroNonce = errors.New("k0 is zero")
+	// ErrNonceReuse is returned by SafeSigner when it would sign two different
+	// messages with the same nonce.
+	ErrNonceReuse = errors.New("the same nonce was already used for a different message")
+	// ErrNonceCommitment is returned when a revealed public nonce doesn't match
+	// the commitment its signer sent before.
+	ErrNonceCommitment = errors.New("the nonce does not match its commitment")
+	// ErrPointAtInfinity is returned when points that were added together cancel
+	// out.
+	ErrPointAtInfinity = errors.New("the result is the point at infinity")
+	// ErrTweakOutOfRange is returned when a tweak is not below n, or cancels out
+	// the key it is added to.
+	ErrTweakOutOfRange = errors.New("the tweak must be below n and must not cancel out the key")
+	// ErrVerifyFailed is returned when a signature is not valid.
+	ErrVerifyFailed = errors.New("signature verification failed")
+)
@@ -0,0 +1,79 @@
+package schnorr
+
+import (
+	"math/big"
+)
+
+// VerifyTrace holds the intermediate values of a verification, for debugging
+// signatures that don't verify. Values that weren't reached are nil.
+type VerifyTrace struct {
+	// Px and Py are the public key point, with the even y.
+	Px, Py *big.Int
+	// R and S are the two halves of the signature.
+	R, S *big.Int
+	// E is the challenge hash(r || P || m) mod n.
+	E *big.Int
+	// Rx and Ry are the point sG - eP, which must have an even y and an x
+	// equal to R. Both are nil if it is the point at infinity.
+	Rx, Ry *big.Int
+	// Failed tells which check failed, or is empty if the signature is valid.
+	Failed string
+}
+
+// The checks VerifyExplain can report as failed in VerifyTrace.Failed.
+const (
+	FailedPublicKey = "the public key is not the x coordinate of a point on the curve"
+	FailedRTooLarge = "r is not below p"
+	FailedSTooLarge = "s is not below n"
+	FailedSZero     = "s is zero"
+	FailedInfinity  = "sG - eP is the point at infinity"
+	// FailedOddY often means the signature was made with an old draft of
+	// BIP340, which required the y of R to be a quadratic residue instead
+	// of even, and hashed the challenge without a tag and with 33 byte keys.
+	FailedOddY      = "sG - eP has an odd y"
+	FailedRMismatch = "the x of sG - eP is not r"
+)
+
+// VerifyExplain verifies a signature like Verify, also returning the
+// intermediate values and which check failed, if any. It is slower than
+// Verify and only meant for debugging and teaching.
+func VerifyExplain(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, *VerifyTrace, error) {
+	trace := &VerifyTrace{}
+	P, ok := liftPublicKey(publicKey)
+	if !ok {
+		trace.Failed = FailedPublicKey
+		return false, trace, ValidatePublicKey(publicKey[:])
+	}
+	trace.Px, trace.Py = P.x.int(), P.y.int()
+
+	trace.R = new(big.Int).SetBytes(signature[:32])
+	trace.S = new(big.Int).SetBytes(signature[32:])
+	switch {
+	case trace.R.Cmp(Curve.P) >= 0:
+		trace.Failed = FailedRTooLarge
+		return false, trace, ErrRTooLarge
+	case trace.S.Cmp(Curve.N) >= 0:
+		trace.Failed = FailedSTooLarge
+		return false, trace, ErrSTooLarge
+	case trace.S.Sign() == 0:
+		trace.Failed = FailedSZero
+		return false, trace, ErrVerifyFailed
+	}
+
+	trace.E = challenge(signature[:32], publicKey[:], message[:])
+	R := multiScalarMult([]affinePoint{generator, P}, []*big.Int{trace.S, NegateChallenge(trace.E)})
+	if R.isInfinity() {
+		trace.Failed = FailedInfinity
+		return false, trace, ErrVerifyFailed
+	}
+	trace.Rx, trace.Ry = R.affine()
+	switch {
+	case trace.Ry.Bit(0) == 1:
+		trace.Failed = FailedOddY
+		return false, trace, ErrVerifyFailed
+	case trace.Rx.Cmp(trace.R) != 0:
+		trace.Failed = FailedRMismatch
+		return false, trace, ErrVerifyFailed
+	}
+	return true, trace, nil
+}
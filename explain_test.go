@@ -0,0 +1,56 @@
+package schnorr
+
+import (
+	"encoding/csv"
+	"os"
+	"testing"
+)
+
+func TestVerifyExplain(t *testing.T) {
+	f, err := os.Open(TEST_CASES_CSV)
+	if err != nil {
+		t.Fatalf("Failed to open test cases from %s: %v", TEST_CASES_CSV, err)
+	}
+	defer f.Close()
+	testCases, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV file: %v", err)
+	}
+
+	// the checks the vectors are known to fail, the others can fail either
+	// of the last two.
+	failed := map[string]string{
+		"5":  FailedPublicKey,
+		"6":  FailedOddY,
+		"9":  FailedInfinity,
+		"10": FailedInfinity,
+		"12": FailedRTooLarge,
+		"13": FailedSTooLarge,
+		"14": FailedPublicKey,
+	}
+	for _, testCase := range testCases[1:] {
+		index := testCase[0]
+		publicKey := decodePublicKey(testCase[2], t)
+		message := decodeMessage(testCase[4], t)
+		signature := decodeSignature(testCase[5], t)
+
+		ok, trace, err := VerifyExplain(publicKey, message, signature)
+		expectedOK, expectedErr := Verify(publicKey, message, signature)
+		if ok != expectedOK || (err == nil) != (expectedErr == nil) {
+			t.Fatalf("[%s] VerifyExplain = %v, %v, Verify = %v, %v", index, ok, err, expectedOK, expectedErr)
+		}
+		if ok != (trace.Failed == "") {
+			t.Fatalf("[%s] VerifyExplain = %v with Failed %q", index, ok, trace.Failed)
+		}
+		want, known := failed[index]
+		switch {
+		case known && trace.Failed != want:
+			t.Fatalf("[%s] VerifyExplain failed with %q, want %q", index, trace.Failed, want)
+		case !ok && !known && trace.Failed != FailedOddY && trace.Failed != FailedRMismatch:
+			t.Fatalf("[%s] VerifyExplain failed with %q", index, trace.Failed)
+		}
+		if ok && (trace.E == nil || trace.Rx.Cmp(trace.R) != 0 || trace.Ry.Bit(0) != 0) {
+			t.Fatalf("[%s] VerifyExplain trace of a valid signature = %+v", index, trace)
+		}
+	}
+}
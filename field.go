@@ -0,0 +1,270 @@
+package schnorr
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fieldVal is an element of the secp256k1 base field, stored as four 64-bit
+// little-endian limbs and always kept fully reduced modulo P.
+//
+// It exists so the batch routines can do many point additions without going
+// through big.Int and an inversion for every single step.
+type fieldVal [4]uint64
+
+// fieldC is 2^256 - P, so 2^256 = fieldC (mod P).
+const fieldC = 0x1000003D1
+
+var fieldOne = fieldVal{1, 0, 0, 0}
+
+func (f *fieldVal) setBytes(b []byte) *fieldVal {
+	for i := 0; i < 4; i++ {
+		var limb uint64
+		for j := 0; j < 8; j++ {
+			limb = limb<<8 | uint64(b[24-i*8+j])
+		}
+		f[i] = limb
+	}
+	return f.normalize(0)
+}
+
+func (f *fieldVal) setInt(i *big.Int) *fieldVal {
+	var b [32]byte
+	intToByteInto(b[:], i)
+	return f.setBytes(b[:])
+}
+
+func (f *fieldVal) bytes() []byte {
+	b := make([]byte, 32)
+	f.putBytes(b)
+	return b
+}
+
+// putBytes is bytes writing into the first 32 bytes of b.
+func (f *fieldVal) putBytes(b []byte) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-i*8-j] = byte(f[i] >> (8 * j))
+		}
+	}
+}
+
+func (f *fieldVal) int() *big.Int {
+	return new(big.Int).SetBytes(f.bytes())
+}
+
+func (f *fieldVal) isZero() bool {
+	return f[0]|f[1]|f[2]|f[3] == 0
+}
+
+// isZeroFlag is isZero as 1 or 0, without branching.
+func (f *fieldVal) isZeroFlag() uint64 {
+	return ctEqual(f[0]|f[1]|f[2]|f[3], 0)
+}
+
+func (f *fieldVal) isOdd() bool {
+	return f[0]&1 == 1
+}
+
+// cmov sets f to a if flag is 1 and leaves it alone if flag is 0, taking the
+// same time either way.
+func (f *fieldVal) cmov(a *fieldVal, flag uint64) {
+	mask := -flag
+	f[0] = f[0]&^mask | a[0]&mask
+	f[1] = f[1]&^mask | a[1]&mask
+	f[2] = f[2]&^mask | a[2]&mask
+	f[3] = f[3]&^mask | a[3]&mask
+}
+
+// ctEqual returns 1 if a == b and 0 otherwise, without branching.
+func ctEqual(a, b uint64) uint64 {
+	x := a ^ b
+	return 1 ^ ((x | -x) >> 63)
+}
+
+func (f *fieldVal) equals(g *fieldVal) bool {
+	return (f[0]^g[0])|(f[1]^g[1])|(f[2]^g[2])|(f[3]^g[3]) == 0
+}
+
+// normalize reduces f, given the carry bit of whatever produced it, to the
+// range 0..P-1. The value is assumed to be smaller than 2P.
+func (f *fieldVal) normalize(carry uint64) *fieldVal {
+	var t fieldVal
+	var c uint64
+	t[0], c = bits.Add64(f[0], fieldC, 0)
+	t[1], c = bits.Add64(f[1], 0, c)
+	t[2], c = bits.Add64(f[2], 0, c)
+	t[3], c = bits.Add64(f[3], 0, c)
+
+	// f >= P exactly when f + 2^256 - P overflows.
+	mask := -(carry | c)
+	f[0] = f[0]&^mask | t[0]&mask
+	f[1] = f[1]&^mask | t[1]&mask
+	f[2] = f[2]&^mask | t[2]&mask
+	f[3] = f[3]&^mask | t[3]&mask
+	return f
+}
+
+func (f *fieldVal) add(a, b *fieldVal) *fieldVal {
+	var c uint64
+	f[0], c = bits.Add64(a[0], b[0], 0)
+	f[1], c = bits.Add64(a[1], b[1], c)
+	f[2], c = bits.Add64(a[2], b[2], c)
+	f[3], c = bits.Add64(a[3], b[3], c)
+	return f.normalize(c)
+}
+
+func (f *fieldVal) sub(a, b *fieldVal) *fieldVal {
+	var c uint64
+	f[0], c = bits.Sub64(a[0], b[0], 0)
+	f[1], c = bits.Sub64(a[1], b[1], c)
+	f[2], c = bits.Sub64(a[2], b[2], c)
+	f[3], c = bits.Sub64(a[3], b[3], c)
+
+	// on borrow add P back, which is the same as subtracting 2^256 - P.
+	mask := -c
+	f[0], c = bits.Sub64(f[0], fieldC&mask, 0)
+	f[1], c = bits.Sub64(f[1], 0, c)
+	f[2], c = bits.Sub64(f[2], 0, c)
+	f[3], _ = bits.Sub64(f[3], 0, c)
+	return f
+}
+
+func (f *fieldVal) neg(a *fieldVal) *fieldVal {
+	return f.sub(&fieldVal{}, a)
+}
+
+func (f *fieldVal) mul(a, b *fieldVal) *fieldVal {
+	var t [8]uint64
+	var c uint64
+	c, t[0] = mulAdd(a[0], b[0], 0, 0)
+	c, t[1] = mulAdd(a[0], b[1], 0, c)
+	c, t[2] = mulAdd(a[0], b[2], 0, c)
+	t[4], t[3] = mulAdd(a[0], b[3], 0, c)
+
+	c, t[1] = mulAdd(a[1], b[0], t[1], 0)
+	c, t[2] = mulAdd(a[1], b[1], t[2], c)
+	c, t[3] = mulAdd(a[1], b[2], t[3], c)
+	t[5], t[4] = mulAdd(a[1], b[3], t[4], c)
+
+	c, t[2] = mulAdd(a[2], b[0], t[2], 0)
+	c, t[3] = mulAdd(a[2], b[1], t[3], c)
+	c, t[4] = mulAdd(a[2], b[2], t[4], c)
+	t[6], t[5] = mulAdd(a[2], b[3], t[5], c)
+
+	c, t[3] = mulAdd(a[3], b[0], t[3], 0)
+	c, t[4] = mulAdd(a[3], b[1], t[4], c)
+	c, t[5] = mulAdd(a[3], b[2], t[5], c)
+	t[7], t[6] = mulAdd(a[3], b[3], t[6], c)
+
+	return f.reduce(&t)
+}
+
+func (f *fieldVal) square(a *fieldVal) *fieldVal {
+	return f.mul(a, a)
+}
+
+// squareN squares a n times.
+func (f *fieldVal) squareN(a *fieldVal, n int) *fieldVal {
+	*f = *a
+	for i := 0; i < n; i++ {
+		f.mul(f, f)
+	}
+	return f
+}
+
+// mulAdd returns a*b + c + d as a 128-bit hi, lo pair, which can't overflow.
+func mulAdd(a, b, c, d uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(a, b)
+	var carry uint64
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, d, 0)
+	hi += carry
+	return hi, lo
+}
+
+// reduce folds a 512-bit product into f using 2^256 = fieldC (mod P).
+func (f *fieldVal) reduce(t *[8]uint64) *fieldVal {
+	var r0, r1, r2, r3, c uint64
+	c, r0 = mulAdd(t[4], fieldC, t[0], 0)
+	c, r1 = mulAdd(t[5], fieldC, t[1], c)
+	c, r2 = mulAdd(t[6], fieldC, t[2], c)
+	c, r3 = mulAdd(t[7], fieldC, t[3], c)
+
+	// c is at most 34 bits, fold it once more.
+	hi, lo := bits.Mul64(c, fieldC)
+	r0, c = bits.Add64(r0, lo, 0)
+	r1, c = bits.Add64(r1, hi, c)
+	r2, c = bits.Add64(r2, 0, c)
+	r3, c = bits.Add64(r3, 0, c)
+
+	// wrapping past 2^256 leaves a tiny value, so this can't overflow again.
+	f[0], c = bits.Add64(r0, fieldC&-c, 0)
+	f[1], c = bits.Add64(r1, 0, c)
+	f[2], c = bits.Add64(r2, 0, c)
+	f[3], _ = bits.Add64(r3, 0, c)
+	return f.normalize(0)
+}
+
+// powChain sets f to a^(2^223 - 1) and returns the intermediate powers
+// a^(2^2 - 1) and a^(2^22 - 1), which is what both inverse and sqrt start with.
+// These are the addition chains used by libsecp256k1.
+func (f *fieldVal) powChain(a *fieldVal) (x2, x22 fieldVal) {
+	var x3, x6, x9, x11, x44, x88, x176, x220, t fieldVal
+	x2.square(a)
+	x2.mul(&x2, a)
+	x3.square(&x2)
+	x3.mul(&x3, a)
+	x6.squareN(&x3, 3)
+	x6.mul(&x6, &x3)
+	x9.squareN(&x6, 3)
+	x9.mul(&x9, &x3)
+	x11.squareN(&x9, 2)
+	x11.mul(&x11, &x2)
+	x22.squareN(&x11, 11)
+	x22.mul(&x22, &x11)
+	x44.squareN(&x22, 22)
+	x44.mul(&x44, &x22)
+	x88.squareN(&x44, 44)
+	x88.mul(&x88, &x44)
+	x176.squareN(&x88, 88)
+	x176.mul(&x176, &x88)
+	x220.squareN(&x176, 44)
+	x220.mul(&x220, &x44)
+	t.squareN(&x220, 3)
+	f.mul(&t, &x3)
+	return x2, x22
+}
+
+// inverse sets f to a^(P-2), which is 1/a for any a other than zero.
+func (f *fieldVal) inverse(a *fieldVal) *fieldVal {
+	var r fieldVal
+	x2, x22 := r.powChain(a)
+	r.squareN(&r, 23)
+	r.mul(&r, &x22)
+	r.squareN(&r, 5)
+	r.mul(&r, a)
+	r.squareN(&r, 3)
+	r.mul(&r, &x2)
+	r.squareN(&r, 2)
+	*f = *r.mul(&r, a)
+	return f
+}
+
+// sqrt sets f to a^((P+1)/4), which is a square root of a if there is one, and
+// reports whether a actually was a square. It is the package's only check for
+// quadratic residues: liftX, and through it Unmarshal, rely on its result.
+func (f *fieldVal) sqrt(a *fieldVal) bool {
+	var r, check fieldVal
+	x2, x22 := r.powChain(a)
+	r.squareN(&r, 23)
+	r.mul(&r, &x22)
+	r.squareN(&r, 6)
+	r.mul(&r, &x2)
+	r.squareN(&r, 2)
+	check.square(&r)
+	ok := check.equals(a)
+	*f = r
+	return ok
+}
@@ -0,0 +1,83 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestFieldArithmetic(t *testing.T) {
+	P := Curve.P
+	values := []*big.Int{Zero, One, new(big.Int).Sub(P, One), new(big.Int).Sub(P, Two)}
+	for i := 0; i < 50; i++ {
+		v, _ := rand.Int(rand.Reader, P)
+		values = append(values, v)
+	}
+
+	for _, x := range values {
+		for _, y := range values[:10] {
+			var a, b, r fieldVal
+			a.setInt(x)
+			b.setInt(y)
+
+			expected := new(big.Int).Mod(new(big.Int).Add(x, y), P)
+			if r.add(&a, &b); r.int().Cmp(expected) != 0 {
+				t.Fatalf("%x + %x = %x, want %x", x, y, r.int(), expected)
+			}
+			expected = new(big.Int).Mod(new(big.Int).Sub(x, y), P)
+			if r.sub(&a, &b); r.int().Cmp(expected) != 0 {
+				t.Fatalf("%x - %x = %x, want %x", x, y, r.int(), expected)
+			}
+			expected = new(big.Int).Mod(new(big.Int).Mul(x, y), P)
+			if r.mul(&a, &b); r.int().Cmp(expected) != 0 {
+				t.Fatalf("%x * %x = %x, want %x", x, y, r.int(), expected)
+			}
+		}
+
+		var a, r fieldVal
+		a.setInt(x)
+		if x.Sign() != 0 {
+			expected := new(big.Int).ModInverse(x, P)
+			if r.inverse(&a); r.int().Cmp(expected) != 0 {
+				t.Fatalf("1/%x = %x, want %x", x, r.int(), expected)
+			}
+		}
+		expected := new(big.Int).ModSqrt(x, P)
+		ok := r.sqrt(&a)
+		if ok != (expected != nil) {
+			t.Fatalf("sqrt(%x) ok = %v, want %v", x, ok, expected != nil)
+		}
+		if ok {
+			var sq fieldVal
+			if sq.square(&r); !sq.equals(&a) {
+				t.Fatalf("sqrt(%x)² = %x", x, sq.int())
+			}
+		}
+	}
+}
+
+func TestSqrtResidues(t *testing.T) {
+	// -1 is not a square as p = 3 mod 4, 7 is not one either, which is why
+	// x = 0 has no point.
+	minusOne := new(big.Int).Sub(Curve.P, One)
+	tests := []struct {
+		a    *big.Int
+		want bool
+	}{
+		{Zero, true},
+		{One, true},
+		{Four, true},
+		{new(big.Int).Exp(Curve.Gy, Two, Curve.P), true},
+		{new(big.Int).Sub(Curve.P, Four), false},
+		{minusOne, false},
+		{Seven, false},
+		{new(big.Int).Exp(Seven, Three, Curve.P), false},
+	}
+	for _, test := range tests {
+		var a, r fieldVal
+		a.setInt(test.a)
+		if observed := r.sqrt(&a); observed != test.want {
+			t.Fatalf("sqrt(%x) ok = %v, want %v", test.a, observed, test.want)
+		}
+	}
+}
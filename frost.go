@@ -0,0 +1,390 @@
+package schnorr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+)
+
+// FROST lets any threshold of the participants produce a signature under one
+// group public key, without the group private key ever existing in one place.
+// Like with MuSig the result is an ordinary BIP340 signature.
+//
+// Participants are numbered 1..n. To generate the key, every participant:
+//
+//  1. calls NewFROSTKeyGen and broadcasts its Commitment,
+//  2. sends Share(j) to every other participant j, over a private channel,
+//  3. calls Finish with everybody's commitments and the shares it got.
+//
+// To sign, at least threshold of them:
+//
+//  1. call GenerateNonce and send the public nonce to the others,
+//  2. call FROSTSign with all the nonces and send the partial signature to
+//     whoever combines them with FROSTAggregate.
+//
+// https://eprint.iacr.org/2020/852
+
+// FROSTCommitment is what a participant broadcasts during the key generation:
+// commitments to the coefficients of its secret polynomial, in compressed form,
+// and a proof that it knows the first one.
+type FROSTCommitment struct {
+	Index        int
+	Coefficients [][33]byte
+	ProofR       [33]byte
+	ProofS       [32]byte
+}
+
+// FROSTKeyGen holds the secret polynomial of one participant during the key
+// generation.
+type FROSTKeyGen struct {
+	index, threshold, participants int
+	coefficients                   []*big.Int
+	commitment                     FROSTCommitment
+}
+
+// FROSTKey is what a participant keeps after the key generation. It can be
+// stored field by field and put back together to sign later.
+type FROSTKey struct {
+	Index, Threshold, Participants int
+	// Share is the share of the group private key, which must be kept secret.
+	Share *big.Int
+	// GroupKey is the public key the signatures verify against.
+	GroupKey [32]byte
+}
+
+// FROSTNonce is the public nonce of a signer, as returned by GenerateNonce.
+type FROSTNonce struct {
+	Index int
+	Nonce [64]byte
+}
+
+// NewFROSTKeyGen starts the key generation for participant index out of
+// participants, threshold of which will be needed to sign. Calling with a nil
+// random will cause the function to use crypto/rand.
+func NewFROSTKeyGen(random io.Reader, index, threshold, participants int) (*FROSTKeyGen, error) {
+	if threshold < 1 || threshold > participants {
+		return nil, errors.New("threshold must be in the range 1..participants")
+	}
+	if index < 1 || index > participants {
+		return nil, errors.New("index must be in the range 1..participants")
+	}
+
+	kg := &FROSTKeyGen{
+		index:        index,
+		threshold:    threshold,
+		participants: participants,
+		coefficients: make([]*big.Int, threshold),
+		commitment:   FROSTCommitment{Index: index, Coefficients: make([][33]byte, threshold)},
+	}
+	for i := range kg.coefficients {
+		a, err := randomScalar(random)
+		if err != nil {
+			return nil, err
+		}
+		kg.coefficients[i] = a
+		copy(kg.commitment.Coefficients[i][:], compressPoint(baseMultJacobian(a)))
+	}
+
+	// a Schnorr signature with the first coefficient, so nobody can pick a
+	// polynomial that cancels out the others.
+	k, err := randomScalar(random)
+	if err != nil {
+		return nil, err
+	}
+	copy(kg.commitment.ProofR[:], compressPoint(baseMultJacobian(k)))
+	c := frostProofChallenge(&kg.commitment)
+	c.Mul(c, kg.coefficients[0])
+	k.Add(k, c)
+	intToByteInto(kg.commitment.ProofS[:], k.Mod(k, Curve.N))
+	return kg, nil
+}
+
+// Commitment returns what must be broadcast to the other participants.
+func (kg *FROSTKeyGen) Commitment() FROSTCommitment {
+	return kg.commitment
+}
+
+// Share returns the secret share for participant to, which must be sent to it
+// and nobody else. All the shares must be made before calling Finish, which
+// clears the polynomial they come from.
+func (kg *FROSTKeyGen) Share(to int) *big.Int {
+	return evaluatePolynomial(kg.coefficients, to)
+}
+
+// Finish checks the commitments of all the participants, including this one,
+// and the shares they sent, shares[i] coming from the participant who made
+// commitments[i]. It returns the share of the group private key, and once it
+// succeeds the secret polynomial is overwritten with zeros.
+func (kg *FROSTKeyGen) Finish(commitments []FROSTCommitment, shares []*big.Int) (*FROSTKey, error) {
+	if len(commitments) != kg.participants || len(shares) != kg.participants {
+		return nil, errors.New("there must be a commitment and a share from every participant")
+	}
+
+	seen := make(map[int]bool, kg.participants)
+	share := new(big.Int)
+	var groupKey jacobianPoint
+	for i, commitment := range commitments {
+		if commitment.Index < 1 || commitment.Index > kg.participants || seen[commitment.Index] {
+			return nil, fmt.Errorf("commitment %d: bad or repeated index %d", i, commitment.Index)
+		}
+		seen[commitment.Index] = true
+		if len(commitment.Coefficients) != kg.threshold {
+			return nil, fmt.Errorf("commitment %d: must have %d coefficients, not %d", i, kg.threshold, len(commitment.Coefficients))
+		}
+		coefficients := make([]affinePoint, kg.threshold)
+		for j := range coefficients {
+			var ok bool
+			if coefficients[j], ok = decompressAffine(commitment.Coefficients[j][:]); !ok {
+				return nil, fmt.Errorf("commitment %d: coefficient %d is not a valid point", i, j)
+			}
+		}
+
+		// sG = R + cA0
+		R, ok := decompressAffine(commitment.ProofR[:])
+		s, err := ParseScalar(commitment.ProofS[:])
+		if !ok || err != nil {
+			return nil, fmt.Errorf("commitment %d: bad proof", i)
+		}
+		c := frostProofChallenge(&commitment)
+		proof := multiScalarMult(
+			[]affinePoint{newAffinePoint(Curve.Gx, Curve.Gy), R, coefficients[0]},
+			[]*big.Int{s, new(big.Int).Sub(Curve.N, One), c.Sub(Curve.N, c)},
+		)
+		if !proof.isInfinity() {
+			return nil, fmt.Errorf("commitment %d: bad proof", i)
+		}
+
+		// share*G = A0 + index*A1 + index²*A2 + ...
+		if shares[i] == nil || shares[i].Sign() <= 0 || shares[i].Cmp(Curve.N) >= 0 {
+			return nil, fmt.Errorf("share %d is out of range", i)
+		}
+		scalars := make([]*big.Int, kg.threshold+1)
+		x := big.NewInt(int64(kg.index))
+		power := big.NewInt(1)
+		for j := 0; j < kg.threshold; j++ {
+			scalars[j] = new(big.Int).Set(power)
+			power.Mul(power, x).Mod(power, Curve.N)
+		}
+		scalars[kg.threshold] = new(big.Int).Sub(Curve.N, shares[i])
+		check := multiScalarMult(append(coefficients, newAffinePoint(Curve.Gx, Curve.Gy)), scalars)
+		if !check.isInfinity() {
+			return nil, fmt.Errorf("share %d doesn't match commitment %d", i, i)
+		}
+
+		share.Add(share, shares[i])
+		groupKey.addMixed(&groupKey, &coefficients[0])
+	}
+
+	Yx, Yy := groupKey.affine()
+	if Yx == nil || share.Mod(share, Curve.N).Sign() == 0 {
+		return nil, ErrPointAtInfinity
+	}
+	// the group key is used with its even y, so if it has an odd one every
+	// participant negates its share, as Sign does with the private key. That
+	// way the shares are all there is to keep.
+	if Yy.Bit(0) == 1 {
+		share.Sub(Curve.N, share)
+	}
+	key := &FROSTKey{
+		Index:        kg.index,
+		Threshold:    kg.threshold,
+		Participants: kg.participants,
+		Share:        share,
+	}
+	intToByteInto(key.GroupKey[:], Yx)
+	for _, a := range kg.coefficients {
+		zeroInt(a)
+	}
+	return key, nil
+}
+
+// FROSTSign makes this signer's share of the signature of message, with the
+// secret nonce from GenerateNonce and the public nonces of all the signers,
+// this one included. There must be at least Threshold of them.
+//
+// secNonce is overwritten with zeros as soon as it is read, like in
+// PartialSign, so passing it again returns ErrNonceOutOfRange.
+func FROSTSign(key *FROSTKey, secNonce *[64]byte, nonces []FROSTNonce, message [32]byte) ([32]byte, error) {
+	partialSig := [32]byte{}
+	d, err1 := ParseScalar(secNonce[:32])
+	e, err2 := ParseScalar(secNonce[32:])
+	zeroBytes(secNonce[:])
+	if err1 != nil || err2 != nil {
+		return partialSig, ErrNonceOutOfRange
+	}
+	defer zeroInt(d)
+	defer zeroInt(e)
+
+	s, err := newFROSTSession(key.GroupKey, key.Threshold, key.Participants, nonces, message)
+	if err != nil {
+		return partialSig, err
+	}
+	rho, ok := s.bindings[key.Index]
+	if !ok {
+		return partialSig, errors.New("there is no nonce from this signer")
+	}
+
+	share := new(big.Int).Set(key.Share)
+	defer zeroInt(share)
+	if s.negR {
+		d.Sub(Curve.N, d)
+		e.Sub(Curve.N, e)
+	}
+
+	// d + rho*e + lambda*share*c
+	e.Mul(e, rho)
+	share.Mul(share, lagrangeCoefficient(key.Index, s.indices))
+	share.Mul(share, s.c)
+	d.Add(d, e)
+	d.Add(d, share)
+	intToByteInto(partialSig[:], d.Mod(d, Curve.N))
+	return partialSig, nil
+}
+
+// FROSTAggregate adds up the partial signatures, partialSigs[i] coming from the
+// signer who made nonces[i], into a 64 byte signature of message that
+// verifies against the group key, for threshold out of participants.
+func FROSTAggregate(groupKey [32]byte, threshold, participants int, nonces []FROSTNonce, message [32]byte, partialSigs [][32]byte) ([64]byte, error) {
+	sig := [64]byte{}
+	if len(partialSigs) != len(nonces) {
+		return sig, errors.New("all parameters must be an array with the same length")
+	}
+	s, err := newFROSTSession(groupKey, threshold, participants, nonces, message)
+	if err != nil {
+		return sig, err
+	}
+
+	z := new(big.Int)
+	for i, partialSig := range partialSigs {
+		zi, err := ParseScalar(partialSig[:])
+		if err != nil {
+			return sig, fmt.Errorf("partial signature %d: %w", i, err)
+		}
+		z.Add(z, zi)
+	}
+	copy(sig[:32], s.rX)
+	intToByteInto(sig[32:], z.Mod(z, Curve.N))
+	return sig, nil
+}
+
+// frostSession holds what FROSTSign and FROSTAggregate both derive from the
+// nonces and the message.
+type frostSession struct {
+	indices  []int
+	bindings map[int]*big.Int
+	negR     bool
+	rX       []byte
+	c        *big.Int
+}
+
+func newFROSTSession(groupKey [32]byte, threshold, participants int, nonces []FROSTNonce, message [32]byte) (*frostSession, error) {
+	if len(nonces) < threshold {
+		return nil, fmt.Errorf("at least %d signers are needed, not %d", threshold, len(nonces))
+	}
+	Yx := new(big.Int).SetBytes(groupKey[:])
+	Y, ok := liftX(Yx)
+	if !ok {
+		return nil, ErrInvalidPublicKey
+	}
+
+	// every binding factor depends on all the nonces, in the order of the
+	// indices so that everybody gets the same.
+	sorted := append([]FROSTNonce{}, nonces...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	list := make([][]byte, 0, 2*len(sorted))
+	s := &frostSession{
+		indices:  make([]int, len(sorted)),
+		bindings: make(map[int]*big.Int, len(sorted)),
+	}
+	for i, nonce := range sorted {
+		if nonce.Index < 1 || nonce.Index > participants || (i > 0 && nonce.Index == sorted[i-1].Index) {
+			return nil, fmt.Errorf("bad or repeated signer index %d", nonce.Index)
+		}
+		s.indices[i] = nonce.Index
+		list = append(list, intToByte(big.NewInt(int64(nonce.Index))), nonce.Nonce[:])
+	}
+
+	// R = D1 + rho1*E1 + D2 + rho2*E2 + ...
+	points := make([]affinePoint, 0, 2*len(sorted))
+	scalars := make([]*big.Int, 0, 2*len(sorted))
+	for _, nonce := range sorted {
+		D, okD := liftX(new(big.Int).SetBytes(nonce.Nonce[:32]))
+		E, okE := liftX(new(big.Int).SetBytes(nonce.Nonce[32:]))
+		if !okD || !okE {
+			return nil, fmt.Errorf("nonce of signer %d is not a valid point", nonce.Index)
+		}
+		data := append([][]byte{groupKey[:], message[:], intToByte(big.NewInt(int64(nonce.Index)))}, list...)
+		rho := hashToScalar(taggedHash("FROST/binding", data...))
+		s.bindings[nonce.Index] = rho
+		points = append(points, D, E)
+		scalars = append(scalars, One, rho)
+	}
+	R := multiScalarMult(points, scalars)
+	if R.isInfinity() {
+		return nil, ErrPointAtInfinity
+	}
+	Rx, Ry := R.affine()
+
+	s.negR = Ry.Bit(0) == 1
+	s.rX = intToByte(Rx)
+	s.c = getE(Yx, Y.y.int(), s.rX, message)
+	return s, nil
+}
+
+// frostProofChallenge is the challenge of the proof in a key generation
+// commitment.
+func frostProofChallenge(commitment *FROSTCommitment) *big.Int {
+	return hashToScalar(taggedHash("FROST/keygen",
+		intToByte(big.NewInt(int64(commitment.Index))),
+		commitment.Coefficients[0][:],
+		commitment.ProofR[:],
+	))
+}
+
+// evaluatePolynomial returns the polynomial with the given coefficients, lowest
+// degree first, at x, modulo n.
+func evaluatePolynomial(coefficients []*big.Int, x int) *big.Int {
+	result := new(big.Int)
+	bx := big.NewInt(int64(x))
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, bx)
+		result.Add(result, coefficients[i])
+		result.Mod(result, Curve.N)
+	}
+	return result
+}
+
+// lagrangeCoefficient returns the coefficient of the share of participant i
+// when interpolating at 0 from the shares of indices.
+func lagrangeCoefficient(i int, indices []int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		den.Mul(den, big.NewInt(int64(j-i)))
+	}
+	den.Mod(den, Curve.N)
+	num.Mul(num, den.ModInverse(den, Curve.N))
+	return num.Mod(num, Curve.N)
+}
+
+// baseMultJacobian is baseMult for callers that want a jacobianPoint.
+func baseMultJacobian(k *big.Int) *jacobianPoint {
+	x, y := baseMult(k)
+	a := newAffinePoint(x, y)
+	return new(jacobianPoint).setAffine(&a)
+}
+
+// decompressAffine is decompressPoint for points that can't be infinity.
+func decompressAffine(b []byte) (affinePoint, bool) {
+	p, ok := decompressPoint(b)
+	if !ok || p.isInfinity() {
+		return affinePoint{}, false
+	}
+	x, y := p.affine()
+	return newAffinePoint(x, y), true
+}
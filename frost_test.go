@@ -0,0 +1,229 @@
+package schnorr
+
+import (
+	"math/big"
+	"testing"
+)
+
+// frostKeyGen runs the whole key generation for n participants.
+func frostKeyGen(threshold, n int, t *testing.T) []*FROSTKey {
+	keyGens := make([]*FROSTKeyGen, n)
+	commitments := make([]FROSTCommitment, n)
+	for i := range keyGens {
+		kg, err := NewFROSTKeyGen(nil, i+1, threshold, n)
+		if err != nil {
+			t.Fatalf("Unexpected error from NewFROSTKeyGen: %v", err)
+		}
+		keyGens[i], commitments[i] = kg, kg.Commitment()
+	}
+
+	// all the shares are made before Finish clears the polynomials.
+	shares := make([][]*big.Int, n)
+	for i := range keyGens {
+		shares[i] = make([]*big.Int, n)
+		for j := range shares[i] {
+			shares[i][j] = keyGens[j].Share(i + 1)
+		}
+	}
+
+	keys := make([]*FROSTKey, n)
+	for i, kg := range keyGens {
+		key, err := kg.Finish(commitments, shares[i])
+		if err != nil {
+			t.Fatalf("Unexpected error from FROSTKeyGen.Finish: %v", err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestFROST(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "any three of five")
+
+	keys := frostKeyGen(3, 5, t)
+	groupKey := keys[0].GroupKey
+	for _, key := range keys {
+		if key.GroupKey != groupKey {
+			t.Fatalf("participants disagree on the group key: %x and %x", key.GroupKey, groupKey)
+		}
+	}
+
+	for _, signers := range [][]int{{1, 3, 5}, {4, 2, 3}, {1, 2, 3, 4, 5}} {
+		secNonces := make([][64]byte, len(signers))
+		nonces := make([]FROSTNonce, len(signers))
+		for i, index := range signers {
+			var err error
+			nonces[i].Index = index
+			if secNonces[i], nonces[i].Nonce, err = GenerateNonce(nil); err != nil {
+				t.Fatalf("Unexpected error from GenerateNonce: %v", err)
+			}
+		}
+
+		partialSigs := make([][32]byte, len(signers))
+		for i, index := range signers {
+			var err error
+			if partialSigs[i], err = FROSTSign(keys[index-1], &secNonces[i], nonces, message); err != nil {
+				t.Fatalf("Unexpected error from FROSTSign: %v", err)
+			}
+		}
+		signature, err := FROSTAggregate(groupKey, 3, 5, nonces, message, partialSigs)
+		if err != nil {
+			t.Fatalf("Unexpected error from FROSTAggregate: %v", err)
+		}
+		if ok, err := Verify(groupKey, message, signature); !ok {
+			t.Fatalf("Verify of the signature by %v failed: %v", signers, err)
+		}
+
+		// too few signers.
+		secNonce, _, _ := GenerateNonce(nil)
+		if _, err := FROSTSign(keys[signers[0]-1], &secNonce, nonces[:2], message); err == nil || err == ErrNonceOutOfRange {
+			t.Fatalf("FROSTSign with 2 signers out of a threshold of 3 = %v, want an error about the signers", err)
+		}
+	}
+}
+
+func TestFROSTKeyRebuilt(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "any two of three")
+
+	// enough key generations that some group keys have an odd y.
+	for round := 0; round < 8; round++ {
+		keys := frostKeyGen(2, 3, t)
+
+		// what a custody setup would store and load again.
+		rebuilt := make([]*FROSTKey, len(keys))
+		for i, key := range keys {
+			rebuilt[i] = &FROSTKey{
+				Index:        key.Index,
+				Threshold:    key.Threshold,
+				Participants: key.Participants,
+				Share:        new(big.Int).Set(key.Share),
+				GroupKey:     key.GroupKey,
+			}
+		}
+
+		// the shares interpolate to the private key of the even y point.
+		x := new(big.Int)
+		for _, key := range rebuilt[:2] {
+			term := lagrangeCoefficient(key.Index, []int{1, 2})
+			x.Add(x, term.Mul(term, key.Share))
+		}
+		if publicKey, _ := GetPublicKey(x.Mod(x, Curve.N)); publicKey != keys[0].GroupKey {
+			t.Fatalf("shares interpolate to the key of %x, want %x", publicKey, keys[0].GroupKey)
+		}
+		if _, Py := baseMult(x); Py.Bit(0) != 0 {
+			t.Fatalf("shares interpolate to the key of a point with an odd y")
+		}
+
+		secNonces := make([][64]byte, 2)
+		nonces := make([]FROSTNonce, 2)
+		for i := range nonces {
+			nonces[i].Index = rebuilt[i].Index
+			secNonces[i], nonces[i].Nonce, _ = GenerateNonce(nil)
+		}
+		partialSigs := make([][32]byte, 2)
+		for i := range partialSigs {
+			var err error
+			if partialSigs[i], err = FROSTSign(rebuilt[i], &secNonces[i], nonces, message); err != nil {
+				t.Fatalf("Unexpected error from FROSTSign: %v", err)
+			}
+		}
+		signature, err := FROSTAggregate(keys[0].GroupKey, 2, 3, nonces, message, partialSigs)
+		if err != nil {
+			t.Fatalf("Unexpected error from FROSTAggregate: %v", err)
+		}
+		if ok, err := Verify(keys[0].GroupKey, message, signature); !ok {
+			t.Fatalf("Verify of a signature by rebuilt keys failed: %v", err)
+		}
+	}
+}
+
+func TestFROSTSecrets(t *testing.T) {
+	var message [32]byte
+	keys := frostKeyGen(2, 3, t)
+
+	nonces := make([]FROSTNonce, 2)
+	secNonces := make([][64]byte, 2)
+	for i := range nonces {
+		nonces[i].Index = keys[i].Index
+		secNonces[i], nonces[i].Nonce, _ = GenerateNonce(nil)
+	}
+	if _, err := FROSTSign(keys[0], &secNonces[0], nonces, message); err != nil {
+		t.Fatalf("Unexpected error from FROSTSign: %v", err)
+	}
+	if secNonces[0] != [64]byte{} {
+		t.Fatalf("FROSTSign left the secret nonce as it was")
+	}
+	if _, err := FROSTSign(keys[0], &secNonces[0], nonces, message); err != ErrNonceOutOfRange {
+		t.Fatalf("FROSTSign with a used nonce = %v, want %v", err, ErrNonceOutOfRange)
+	}
+
+	// signer indices must be those of participants.
+	for _, index := range []int{0, 4} {
+		bad := append([]FROSTNonce{}, nonces...)
+		bad[1].Index = index
+		secNonce, _, _ := GenerateNonce(nil)
+		if _, err := FROSTSign(keys[0], &secNonce, bad, message); err == nil || err == ErrNonceOutOfRange {
+			t.Fatalf("FROSTSign with a nonce from signer %d out of 3 succeeded", index)
+		}
+		if _, err := FROSTAggregate(keys[0].GroupKey, 2, 3, bad, message, make([][32]byte, 2)); err == nil {
+			t.Fatalf("FROSTAggregate with a nonce from signer %d out of 3 succeeded", index)
+		}
+	}
+}
+
+func TestFROSTKeyGenClearsPolynomial(t *testing.T) {
+	keyGens := make([]*FROSTKeyGen, 2)
+	commitments := make([]FROSTCommitment, 2)
+	for i := range keyGens {
+		keyGens[i], _ = NewFROSTKeyGen(nil, i+1, 2, 2)
+		commitments[i] = keyGens[i].Commitment()
+	}
+	shares := []*big.Int{keyGens[0].Share(1), keyGens[1].Share(1)}
+	if _, err := keyGens[0].Finish(commitments, shares); err != nil {
+		t.Fatalf("Unexpected error from FROSTKeyGen.Finish: %v", err)
+	}
+	for i, a := range keyGens[0].coefficients {
+		if a.Sign() != 0 {
+			t.Fatalf("coefficient %d is still there after Finish", i)
+		}
+	}
+}
+
+func TestFROSTKeyGenBadShare(t *testing.T) {
+	keyGens := make([]*FROSTKeyGen, 3)
+	commitments := make([]FROSTCommitment, 3)
+	for i := range keyGens {
+		keyGens[i], _ = NewFROSTKeyGen(nil, i+1, 2, 3)
+		commitments[i] = keyGens[i].Commitment()
+	}
+	shares := []*big.Int{keyGens[0].Share(1), keyGens[1].Share(1), keyGens[2].Share(2)}
+	if _, err := keyGens[0].Finish(commitments, shares); err == nil {
+		t.Fatalf("FROSTKeyGen.Finish accepted a share meant for somebody else")
+	}
+
+	shares[2] = keyGens[2].Share(1)
+	commitments[1].ProofS[31] ^= 1
+	if _, err := keyGens[0].Finish(commitments, shares); err == nil {
+		t.Fatalf("FROSTKeyGen.Finish accepted a bad proof")
+	}
+
+	if _, err := NewFROSTKeyGen(nil, 1, 4, 3); err == nil {
+		t.Fatalf("NewFROSTKeyGen with a threshold above the participants succeeded")
+	}
+}
+
+func TestLagrangeCoefficient(t *testing.T) {
+	// any 3 points of a degree 2 polynomial give back its value at 0.
+	coefficients := []*big.Int{big.NewInt(42), big.NewInt(7), big.NewInt(1000)}
+	indices := []int{2, 5, 9}
+	result := new(big.Int)
+	for _, i := range indices {
+		term := new(big.Int).Mul(evaluatePolynomial(coefficients, i), lagrangeCoefficient(i, indices))
+		result.Add(result, term)
+	}
+	if result.Mod(result, Curve.N).Cmp(big.NewInt(42)) != 0 {
+		t.Fatalf("interpolation at 0 = %v, want 42", result)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package schnorr
+
+import (
+	"testing"
+)
+
+// FuzzVerify checks that Verify doesn't panic on any input, and only says a
+// signature is valid when it has no error to report.
+func FuzzVerify(f *testing.F) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", f)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", f)
+	publicKey, _ := GetPublicKey(d)
+	signature, _ := Sign(d, message, nil)
+
+	f.Add(publicKey[:], message[:], signature[:])
+	f.Add(make([]byte, 32), make([]byte, 32), make([]byte, 64))
+	f.Add(intToByte(Curve.P), message[:], signature[:])
+	f.Fuzz(func(t *testing.T, publicKey, message, signature []byte) {
+		var pk, m [32]byte
+		var sig [64]byte
+		copy(pk[:], publicKey)
+		copy(m[:], message)
+		copy(sig[:], signature)
+
+		ok, err := Verify(pk, m, sig)
+		if ok != (err == nil) {
+			t.Fatalf("Verify(%x, %x, %x) = %v, %v", pk, m, sig, ok, err)
+		}
+		if public, err := ParsePublicKey(pk[:]); err == nil {
+			if ok2, _ := public.Verify(m, sig); ok2 != ok {
+				t.Fatalf("PublicKey.Verify(%x, %x) = %v, Verify = %v", m, sig, ok2, ok)
+			}
+		}
+	})
+}
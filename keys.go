@@ -0,0 +1,278 @@
+package schnorr
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// PrivateKey is an integer in the range 1..n-1.
+type PrivateKey struct {
+	D *big.Int
+}
+
+// PublicKey is a point on the curve. It is kept decoded so Verify doesn't
+// have to recover y from x every time.
+type PublicKey struct {
+	X, Y *big.Int
+}
+
+// NewPrivateKey checks that d is a valid private key and wraps it.
+func NewPrivateKey(d *big.Int) (*PrivateKey, error) {
+	if d.Cmp(One) < 0 || d.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return nil, ErrKeyOutOfRange
+	}
+	return &PrivateKey{D: new(big.Int).Set(d)}, nil
+}
+
+// Zero overwrites the private key, after which it can't be used to sign
+// anymore. Sign already clears the nonce and the other secrets it computes on
+// the way.
+//
+// This is only a best effort: big.Int gives no control over where its memory
+// goes, so copies made while the key was in use may still be around. It does
+// make it less likely that the key lingers in memory for a long time.
+func (k *PrivateKey) Zero() {
+	zeroInt(k.D)
+}
+
+// Sign a 32 byte digest, returning a 64 byte signature. It implements
+// crypto.Signer, so opts must be nil or hash to crypto.SHA256 or 0 (digest
+// already hashed by the caller). 32 bytes read from rand are used as aux, as
+// in SignRandomized, and a nil rand will cause the function to use a
+// deterministic nonce.
+func (k *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 && opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("%v is not supported, digest must be sha256", opts.HashFunc())
+	}
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadMessageLength, len(digest))
+	}
+
+	message := [32]byte{}
+	copy(message[:], digest)
+	var sig [64]byte
+	var err error
+	if rand != nil {
+		sig, err = SignRandomized(rand, k.D, message)
+	} else {
+		sig, err = Sign(k.D, message, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sig[:], nil
+}
+
+// SignData signs data of any length, see SignMessage.
+func (k *PrivateKey) SignData(data []byte, aux []byte) ([64]byte, error) {
+	return SignMessage(k.D, data, aux)
+}
+
+// Public returns the *PublicKey corresponding to k. It implements
+// crypto.Signer.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	Px, Py := baseMult(k.D)
+	return &PublicKey{X: Px, Y: Py}
+}
+
+// ValidatePublicKey checks that publicKey is a valid public key, either the 32
+// bytes Verify takes or a 33 byte compressed point starting with 2 or 3. It
+// returns ErrBadPublicKeyLength for any other length and ErrInvalidPublicKey
+// for anything else that is not a point on the curve. Verify calls it to say
+// what is wrong with a key it can't use.
+//
+// Neither encoding can express the point at infinity, and secp256k1 has a
+// prime order, so every other point generates the whole group and there are
+// no small subgroups to check for. The check is still spelled out here so
+// that it is explicit.
+func ValidatePublicKey(publicKey []byte) error {
+	switch len(publicKey) {
+	case 32:
+		if _, ok := liftX(new(big.Int).SetBytes(publicKey)); !ok {
+			return ErrInvalidPublicKey
+		}
+		return nil
+	case 33:
+		if publicKey[0] != 2 && publicKey[0] != 3 {
+			return ErrInvalidPublicKey
+		}
+		if _, ok := liftX(new(big.Int).SetBytes(publicKey[1:])); !ok {
+			return ErrInvalidPublicKey
+		}
+		return nil
+	}
+	return fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(publicKey))
+}
+
+// ParsePublicKey decodes a 32 byte public key.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	if len(data) != 32 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(data))
+	}
+	Px, Py := Unmarshal(Curve, data)
+	if Px == nil || Py == nil || !Curve.IsOnCurve(Px, Py) {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{X: Px, Y: Py}, nil
+}
+
+// ParsePublicKeyRaw decodes a public key given as the 64 bytes of x and y with
+// no prefix, returning its 33 byte compressed form. Verify takes the last 32
+// bytes of it, the x-only key.
+func ParsePublicKeyRaw(xy []byte) ([]byte, error) {
+	if len(xy) != 64 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(xy))
+	}
+	Px := new(big.Int).SetBytes(xy[:32])
+	Py := new(big.Int).SetBytes(xy[32:])
+	// IsOnCurve doesn't expect coordinates that are not reduced modulo p.
+	if Px.Cmp(Curve.P) >= 0 || Py.Cmp(Curve.P) >= 0 || !Curve.IsOnCurve(Px, Py) {
+		return nil, ErrInvalidPublicKey
+	}
+	return compressAffineInts(Px, Py), nil
+}
+
+// Serialize returns the 32 byte encoding of the public key.
+func (p *PublicKey) Serialize() [32]byte {
+	pk := [32]byte{}
+	intToByteInto(pk[:], p.X)
+	return pk
+}
+
+// SerializeXOnly returns the 32 byte x-only encoding of the public key, the
+// same as Serialize. It exists under this name for taproot code, which deals
+// with both x-only and compressed keys.
+func (p *PublicKey) SerializeXOnly() [32]byte {
+	return p.Serialize()
+}
+
+// HasEvenY tells whether the y coordinate of the point is even, which is when
+// its 33 byte compressed form starts with 0x02 rather than 0x03. The x-only
+// encoding always stands for the point with the even y, so the private key of
+// a key that doesn't have one must be negated to sign for its x-only form.
+func (p *PublicKey) HasEvenY() bool {
+	return p.Y.Bit(0) == 0
+}
+
+// Equal tells whether x is a *PublicKey for the same point, comparing in
+// constant time. Two keys with the same x but a different y are not equal,
+// even though they have the same 32 byte encoding.
+func (p *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok || other == nil {
+		return false
+	}
+	var a, b [64]byte
+	intToByteInto(a[:32], p.X)
+	intToByteInto(a[32:], p.Y)
+	intToByteInto(b[:32], other.X)
+	intToByteInto(b[32:], other.Y)
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// Verify a 64 byte signature of a 32 byte message, see Verify.
+func (p *PublicKey) Verify(message [32]byte, signature [64]byte) (bool, error) {
+	// p may have been put together by hand rather than by ParsePublicKey.
+	if p.X == nil || p.Y == nil || !Curve.IsOnCurve(p.X, p.Y) {
+		return false, ErrInvalidPublicKey
+	}
+
+	// only x is encoded in the signature, which means the point with even y.
+	P := newAffinePoint(p.X, p.Y)
+	if P.y.isOdd() {
+		P.y.neg(&P.y)
+	}
+	return verify(&P, message, signature, challenge)
+}
+
+// VerifyData tells whether signature is a valid signature of data made by
+// SignData or SignMessage, for callers that don't need to know why not.
+func (p *PublicKey) VerifyData(data []byte, signature [64]byte) bool {
+	ok, _ := p.Verify(hashMessage(data), signature)
+	return ok
+}
+
+// AddPublicKeys returns the point a + b. Both must be on the curve, and the
+// result must not be the point at infinity, which happens when b = -a.
+func AddPublicKeys(a, b *PublicKey) (*PublicKey, error) {
+	if !Curve.IsOnCurve(a.X, a.Y) || !Curve.IsOnCurve(b.X, b.Y) {
+		return nil, ErrInvalidPublicKey
+	}
+	x, y := Curve.Add(a.X, a.Y, b.X, b.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrPointAtInfinity
+	}
+	return &PublicKey{X: x, Y: y}, nil
+}
+
+// NegatePublicKey returns the point -p, which has the same x and so the same
+// 32 byte encoding, but the other y.
+func NegatePublicKey(p *PublicKey) (*PublicKey, error) {
+	if !Curve.IsOnCurve(p.X, p.Y) {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{X: new(big.Int).Set(p.X), Y: new(big.Int).Sub(Curve.P, p.Y)}, nil
+}
+
+// GenerateKeyPair returns a new random private key and its 32 byte public key.
+// Calling with a nil random will cause the function to use crypto/rand.
+func GenerateKeyPair(random io.Reader) (privateKey *big.Int, publicKey [32]byte, err error) {
+	if privateKey, err = randomScalar(random); err != nil {
+		return nil, publicKey, err
+	}
+	publicKey, err = GetPublicKey(privateKey)
+	return privateKey, publicKey, err
+}
+
+// randomScalar draws an integer in the range 1..n-1 from random, or from
+// crypto/rand if it is nil.
+func randomScalar(random io.Reader) (*big.Int, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	// keep drawing until we get something in 1..n-1, reducing modulo n instead
+	// would make the smaller values more likely.
+	b := make([]byte, 32)
+	k := new(big.Int)
+	for {
+		if _, err := io.ReadFull(random, b); err != nil {
+			return nil, err
+		}
+		k.SetBytes(b)
+		if k.Sign() != 0 && k.Cmp(Curve.N) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// ParseScalar decodes a 32 byte big-endian scalar, such as a private key or
+// the s half of a signature, checking that it is in the range 1..n-1.
+func ParseScalar(b []byte) (*big.Int, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadScalarLength, len(b))
+	}
+	k := new(big.Int).SetBytes(b)
+	if k.Sign() == 0 || k.Cmp(Curve.N) >= 0 {
+		return nil, ErrScalarOutOfRange
+	}
+	return k, nil
+}
+
+// GetPublicKey returns the 32 byte public key corresponding to the private key,
+// in the format expected by Verify.
+// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki#public-key-generation
+func GetPublicKey(privateKey *big.Int) ([32]byte, error) {
+	pk := [32]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return pk, ErrKeyOutOfRange
+	}
+
+	Px, _ := baseMult(privateKey)
+	intToByteInto(pk[:], Px)
+	return pk, nil
+}
@@ -0,0 +1,351 @@
+package schnorr
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestGetPublicKey(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	expected := "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"
+
+	pk, err := GetPublicKey(d)
+	if err != nil {
+		t.Fatalf("Unexpected error from GetPublicKey: %v", err)
+	}
+	if observed := hex.EncodeToString(pk[:]); observed != strings.ToLower(expected) {
+		t.Fatalf("GetPublicKey = %s, want %s", observed, expected)
+	}
+
+	for _, d := range []*big.Int{Zero, Curve.N} {
+		if _, err := GetPublicKey(d); err == nil {
+			t.Fatalf("GetPublicKey(%x) should have failed", d)
+		}
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	observed, err := ParseScalar(intToByte(d))
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseScalar: %v", err)
+	}
+	if observed.Cmp(d) != 0 {
+		t.Fatalf("ParseScalar(%x) = %x, want %x", intToByte(d), observed, d)
+	}
+	nMinusOne := new(big.Int).Sub(Curve.N, One)
+	if observed, err := ParseScalar(intToByte(nMinusOne)); err != nil || observed.Cmp(nMinusOne) != 0 {
+		t.Fatalf("ParseScalar(n-1) = %x, %v, want %x", observed, err, nMinusOne)
+	}
+
+	for _, b := range [][]byte{make([]byte, 31), make([]byte, 33), nil} {
+		if _, err := ParseScalar(b); !errors.Is(err, ErrBadScalarLength) {
+			t.Fatalf("ParseScalar with %d bytes = %v, want ErrBadScalarLength", len(b), err)
+		}
+	}
+	for _, k := range []*big.Int{Zero, Curve.N, new(big.Int).Add(Curve.N, One)} {
+		if _, err := ParseScalar(intToByte(k)); err != ErrScalarOutOfRange {
+			t.Fatalf("ParseScalar(%x) = %v, want %v", k, err, ErrScalarOutOfRange)
+		}
+	}
+}
+
+func TestGenerateKeyPair(t *testing.T) {
+	d, pk, err := GenerateKeyPair(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+	}
+	if expected, _ := GetPublicKey(d); pk != expected {
+		t.Fatalf("GenerateKeyPair returned public key %x for %x, want %x", pk, d, expected)
+	}
+
+	// zero and n must be skipped.
+	random := bytes.NewReader(append(append(make([]byte, 32), intToByte(Curve.N)...), intToByte(Seven)...))
+	if d, _, err = GenerateKeyPair(random); err != nil || d.Cmp(Seven) != 0 {
+		t.Fatalf("GenerateKeyPair = %v, %v, want 7", d, err)
+	}
+	if _, _, err = GenerateKeyPair(random); err == nil {
+		t.Fatalf("GenerateKeyPair should fail when the random source is exhausted")
+	}
+}
+
+func TestKeyTypes(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+
+	if _, err := NewPrivateKey(Curve.N); err == nil {
+		t.Fatalf("NewPrivateKey(n) should have failed")
+	}
+	k, err := NewPrivateKey(d)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewPrivateKey: %v", err)
+	}
+
+	var signer crypto.Signer = k
+	sig, err := signer.Sign(nil, message[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Unexpected error from PrivateKey.Sign: %v", err)
+	}
+	signature := decodeSignature(hex.EncodeToString(sig), t)
+	if expected, _ := Sign(d, message, nil); signature != expected {
+		t.Fatalf("PrivateKey.Sign = %x, want %x", signature, expected)
+	}
+
+	// with a rand it is the same as passing 32 bytes of aux.
+	aux := bytes.Repeat([]byte{1}, 32)
+	sig, _ = signer.Sign(bytes.NewReader(aux), message[:], nil)
+	if expected, _ := Sign(d, message, aux); !bytes.Equal(sig, expected[:]) {
+		t.Fatalf("PrivateKey.Sign = %x, want %x", sig, expected)
+	}
+
+	if _, err := signer.Sign(nil, message[:31], nil); err == nil {
+		t.Fatalf("PrivateKey.Sign of a 31 byte digest should have failed")
+	}
+	if _, err := signer.Sign(nil, message[:], crypto.SHA512); err == nil {
+		t.Fatalf("PrivateKey.Sign with crypto.SHA512 should have failed")
+	}
+
+	public := signer.Public().(*PublicKey)
+	serialized := public.Serialize()
+	pub, err := ParsePublicKey(serialized[:])
+	if err != nil {
+		t.Fatalf("Unexpected error from ParsePublicKey: %v", err)
+	}
+
+	// Public keeps the actual point, which may have an odd y, ParsePublicKey always
+	// returns the even one, both must verify.
+	for _, p := range []*PublicKey{public, pub} {
+		if ok, err := p.Verify(message, signature); !ok {
+			t.Fatalf("PublicKey.Verify failed: %v", err)
+		}
+	}
+	message[0] ^= 1
+	if ok, _ := pub.Verify(message, signature); ok {
+		t.Fatalf("PublicKey.Verify succeeded for the wrong message")
+	}
+
+	if _, err := ParsePublicKey(serialized[1:]); err == nil {
+		t.Fatalf("ParsePublicKey of 31 bytes should have failed")
+	}
+}
+
+func TestPrivateKeyZero(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	k, _ := NewPrivateKey(d)
+	words := k.D.Bits()
+	k.Zero()
+
+	if k.D.Sign() != 0 {
+		t.Fatalf("PrivateKey.Zero left %x", k.D)
+	}
+	for _, w := range words {
+		if w != 0 {
+			t.Fatalf("PrivateKey.Zero left words behind: %x", words)
+		}
+	}
+	if _, err := k.Sign(nil, make([]byte, 32), nil); err != ErrKeyOutOfRange {
+		t.Fatalf("PrivateKey.Sign after Zero = %v, want %v", err, ErrKeyOutOfRange)
+	}
+	// the key it was made from is left alone.
+	if d.Sign() == 0 {
+		t.Fatalf("PrivateKey.Zero cleared the key passed to NewPrivateKey")
+	}
+}
+
+func TestPublicKeyArithmetic(t *testing.T) {
+	a := (&PrivateKey{D: big.NewInt(3)}).Public().(*PublicKey)
+	b := (&PrivateKey{D: big.NewInt(4)}).Public().(*PublicKey)
+	seven := (&PrivateKey{D: big.NewInt(7)}).Public().(*PublicKey)
+
+	sum, err := AddPublicKeys(a, b)
+	if err != nil {
+		t.Fatalf("Unexpected error from AddPublicKeys: %v", err)
+	}
+	if sum.X.Cmp(seven.X) != 0 || sum.Y.Cmp(seven.Y) != 0 {
+		t.Fatalf("AddPublicKeys(3G, 4G) = (%x, %x), want 7G", sum.X, sum.Y)
+	}
+
+	negated, err := NegatePublicKey(a)
+	if err != nil {
+		t.Fatalf("Unexpected error from NegatePublicKey: %v", err)
+	}
+	if negated.Serialize() != a.Serialize() || negated.Y.Cmp(a.Y) == 0 || !Curve.IsOnCurve(negated.X, negated.Y) {
+		t.Fatalf("NegatePublicKey(3G) = (%x, %x), want the other y", negated.X, negated.Y)
+	}
+	if _, err := AddPublicKeys(a, negated); err != ErrPointAtInfinity {
+		t.Fatalf("AddPublicKeys(3G, -3G) = %v, want ErrPointAtInfinity", err)
+	}
+
+	offCurve := &PublicKey{X: a.X, Y: new(big.Int).Add(a.Y, One)}
+	if _, err := AddPublicKeys(a, offCurve); err != ErrInvalidPublicKey {
+		t.Fatalf("AddPublicKeys with a point off the curve = %v, want ErrInvalidPublicKey", err)
+	}
+	if _, err := NegatePublicKey(offCurve); err != ErrInvalidPublicKey {
+		t.Fatalf("NegatePublicKey of a point off the curve = %v, want ErrInvalidPublicKey", err)
+	}
+}
+
+func TestSignData(t *testing.T) {
+	d, _, _ := GenerateKeyPair(nil)
+	private := &PrivateKey{D: d}
+	public := private.Public().(*PublicKey)
+
+	for _, data := range [][]byte{nil, {}, []byte("hello"), make([]byte, 32), make([]byte, 1000)} {
+		signature, err := private.SignData(data, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from SignData: %v", err)
+		}
+		if !public.VerifyData(data, signature) {
+			t.Fatalf("VerifyData of %d bytes failed", len(data))
+		}
+		if ok, _ := VerifyMessage(public.Serialize(), data, signature); !ok {
+			t.Fatalf("VerifyMessage of a signature made by SignData failed")
+		}
+		if public.VerifyData(append(data, 0), signature) {
+			t.Fatalf("VerifyData succeeded for different data")
+		}
+	}
+}
+
+func TestPublicKeyParity(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		d, _, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		public := (&PrivateKey{D: d}).Public().(*PublicKey)
+
+		var P jacobianPoint
+		A := newAffinePoint(public.X, public.Y)
+		compressed := compressPoint(P.setAffine(&A))
+		if even := compressed[0] == 2; public.HasEvenY() != even {
+			t.Fatalf("HasEvenY() = %v for a key with the prefix %x", public.HasEvenY(), compressed[0])
+		}
+		if xOnly := public.SerializeXOnly(); string(xOnly[:]) != string(compressed[1:]) {
+			t.Fatalf("SerializeXOnly() = %x, want %x", xOnly, compressed[1:])
+		}
+	}
+}
+
+func TestValidatePublicKey(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	compressed := compressPoint(baseMultJacobian(d))
+	var notOnCurve [32]byte
+	notOnCurve[31] = 5
+
+	tests := []struct {
+		name      string
+		publicKey []byte
+		want      error
+	}{
+		{"x-only", publicKey[:], nil},
+		{"compressed", compressed, nil},
+		{"zeros", make([]byte, 33), ErrInvalidPublicKey},
+		{"x-only off the curve", notOnCurve[:], ErrInvalidPublicKey},
+		{"compressed off the curve", append([]byte{2}, notOnCurve[:]...), ErrInvalidPublicKey},
+		{"bad prefix", append([]byte{4}, publicKey[:]...), ErrInvalidPublicKey},
+		{"x = p", intToByte(Curve.P), ErrInvalidPublicKey},
+	}
+	for _, test := range tests {
+		if err := ValidatePublicKey(test.publicKey); err != test.want {
+			t.Fatalf("ValidatePublicKey(%s) = %v, want %v", test.name, err, test.want)
+		}
+	}
+	for _, n := range []int{0, 1, 31, 65} {
+		if err := ValidatePublicKey(make([]byte, n)); !errors.Is(err, ErrBadPublicKeyLength) {
+			t.Fatalf("ValidatePublicKey of %d bytes = %v, want ErrBadPublicKeyLength", n, err)
+		}
+	}
+
+	// Verify says what is wrong with the key.
+	var message [32]byte
+	signature, _ := Sign(d, message, nil)
+	if ok, err := Verify(notOnCurve, message, signature); ok || err != ErrInvalidPublicKey {
+		t.Fatalf("Verify with a key off the curve = %v, %v, want false, %v", ok, err, ErrInvalidPublicKey)
+	}
+}
+
+func TestParsePublicKeyRaw(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		d, publicKey, _ := GenerateKeyPair(nil)
+		Px, Py := baseMult(d)
+		xy := make([]byte, 64)
+		Px.FillBytes(xy[:32])
+		Py.FillBytes(xy[32:])
+
+		compressed, err := ParsePublicKeyRaw(xy)
+		if err != nil {
+			t.Fatalf("Unexpected error from ParsePublicKeyRaw: %v", err)
+		}
+		if x, y := Unmarshal(Curve, compressed); x == nil || x.Cmp(Px) != 0 || y.Cmp(Py) != 0 {
+			t.Fatalf("Unmarshal(ParsePublicKeyRaw(%x)) = %x, %x, want %x, %x", xy, x, y, Px, Py)
+		}
+		if string(compressed[1:]) != string(publicKey[:]) {
+			t.Fatalf("ParsePublicKeyRaw(%x)[1:] = %x, want %x", xy, compressed[1:], publicKey)
+		}
+
+		xy[63] ^= 1
+		if _, err := ParsePublicKeyRaw(xy); err != ErrInvalidPublicKey {
+			t.Fatalf("ParsePublicKeyRaw of a point off the curve = %v, want %v", err, ErrInvalidPublicKey)
+		}
+	}
+
+	// coordinates that are not reduced modulo p.
+	xy := append(intToByte(Curve.P), intToByte(Curve.P)...)
+	if _, err := ParsePublicKeyRaw(xy); err != ErrInvalidPublicKey {
+		t.Fatalf("ParsePublicKeyRaw(p, p) = %v, want %v", err, ErrInvalidPublicKey)
+	}
+
+	for _, n := range []int{0, 33, 65} {
+		if _, err := ParsePublicKeyRaw(make([]byte, n)); !errors.Is(err, ErrBadPublicKeyLength) {
+			t.Fatalf("ParsePublicKeyRaw of %d bytes = %v, want ErrBadPublicKeyLength", n, err)
+		}
+	}
+}
+
+func TestPublicKeyVerifyOffCurve(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	signature, _ := Sign(d, message, nil)
+	public := (&PrivateKey{D: d}).Public().(*PublicKey)
+
+	// same x as the real key, which is all the challenge hashes.
+	offCurve := &PublicKey{X: public.X, Y: new(big.Int).Add(public.Y, Two)}
+	if ok, err := offCurve.Verify(message, signature); ok || err != ErrInvalidPublicKey {
+		t.Fatalf("PublicKey.Verify with a point off the curve = %v, %v, want ErrInvalidPublicKey", ok, err)
+	}
+	if ok, err := (&PublicKey{X: public.X}).Verify(message, signature); ok || err != ErrInvalidPublicKey {
+		t.Fatalf("PublicKey.Verify with a nil y = %v, %v, want ErrInvalidPublicKey", ok, err)
+	}
+}
+
+func TestPublicKeyEqual(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	public := (&PrivateKey{D: d}).Public().(*PublicKey)
+	same := &PublicKey{X: new(big.Int).Set(public.X), Y: new(big.Int).Set(public.Y)}
+	negated, _ := NegatePublicKey(public)
+	other := (&PrivateKey{D: Seven}).Public().(*PublicKey)
+
+	tests := []struct {
+		x    crypto.PublicKey
+		want bool
+	}{
+		{same, true},
+		{public, true},
+		{negated, false},
+		{other, false},
+		{nil, false},
+		{(*PublicKey)(nil), false},
+		{public.Serialize(), false},
+	}
+	for i, test := range tests {
+		if observed := public.Equal(test.x); observed != test.want {
+			t.Fatalf("[%d] PublicKey.Equal(%v) = %v, want %v", i, test.x, observed, test.want)
+		}
+	}
+}
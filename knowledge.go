@@ -0,0 +1,28 @@
+package schnorr
+
+import (
+	"math/big"
+)
+
+// ProveKnowledge answers an identification challenge sent by a verifier,
+// proving that whoever made the proof holds the private key. This is the
+// Schnorr identification protocol made non-interactive: the proof is a
+// signature of a tagged hash of the challenge, so it can't be taken for a
+// signature of the challenge itself, or of anything else.
+//
+// The proof is only bound to the challenge, so the verifier must pick a fresh
+// random one for each attempt and accept each proof only once, or the proof
+// can be replayed.
+func ProveKnowledge(privateKey *big.Int, challenge []byte) ([64]byte, error) {
+	return SignRandomized(nil, privateKey, knowledgeMessage(challenge))
+}
+
+// VerifyKnowledge verifies a proof made by ProveKnowledge for challenge.
+func VerifyKnowledge(publicKey [32]byte, challenge []byte, proof [64]byte) bool {
+	ok, _ := Verify(publicKey, knowledgeMessage(challenge), proof)
+	return ok
+}
+
+func knowledgeMessage(challenge []byte) [32]byte {
+	return taggedHash("schnorr/knowledge", challenge)
+}
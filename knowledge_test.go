@@ -0,0 +1,42 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestProveKnowledge(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	challenge := make([]byte, 32)
+	rand.Read(challenge)
+
+	proof, err := ProveKnowledge(d, challenge)
+	if err != nil {
+		t.Fatalf("Unexpected error from ProveKnowledge: %v", err)
+	}
+	if !VerifyKnowledge(publicKey, challenge, proof) {
+		t.Fatalf("VerifyKnowledge of a valid proof failed")
+	}
+
+	// a proof for one challenge doesn't answer another.
+	other := make([]byte, 32)
+	rand.Read(other)
+	if VerifyKnowledge(publicKey, other, proof) {
+		t.Fatalf("VerifyKnowledge accepted a proof for another challenge")
+	}
+	_, otherKey, _ := GenerateKeyPair(nil)
+	if VerifyKnowledge(otherKey, challenge, proof) {
+		t.Fatalf("VerifyKnowledge accepted a proof under another key")
+	}
+
+	// nor does a plain signature of the challenge.
+	var message [32]byte
+	copy(message[:], challenge)
+	signature, _ := Sign(d, message, nil)
+	if VerifyKnowledge(publicKey, challenge, signature) {
+		t.Fatalf("VerifyKnowledge accepted a signature of the challenge")
+	}
+	if ok, _ := Verify(publicKey, message, proof); ok {
+		t.Fatalf("the proof verifies as a signature of the challenge")
+	}
+}
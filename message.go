@@ -0,0 +1,102 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"hash"
+	"math/big"
+)
+
+// MessageTag is the BIP340 tag used to hash arbitrary data before signing it
+// with SignMessage. Because of the tag, the digest can't be mistaken for
+// something signed directly with Sign.
+const MessageTag = "schnorr/message"
+
+// SignMessage signs data of any length, hashing it first with the MessageTag
+// tagged hash. Calling with a nil aux will cause the function to use a
+// deterministic nonce, see Sign.
+func SignMessage(privateKey *big.Int, data []byte, aux []byte) ([64]byte, error) {
+	return Sign(privateKey, hashMessage(data), aux)
+}
+
+// VerifyMessage verifies a signature made by SignMessage.
+func VerifyMessage(publicKey [32]byte, data []byte, signature [64]byte) (bool, error) {
+	return Verify(publicKey, hashMessage(data), signature)
+}
+
+// SignAuto signs data with Sign if it is exactly 32 bytes and with
+// SignMessage otherwise, for callers that don't want to pick one.
+//
+// Beware that 32 bytes of data are signed as they are, so they can't be told
+// apart from a digest: a signature of some 32 bytes from SignAuto is also a
+// signature of any data whose hash happens to be those bytes, under whatever
+// hash the verifier has in mind. Data of other lengths gets the MessageTag
+// tagged hash, which keeps it apart from both. Use SignMessage or SignWithTag
+// when the data can be 32 bytes long.
+func SignAuto(privateKey *big.Int, data []byte, aux []byte) ([64]byte, error) {
+	return Sign(privateKey, autoMessage(data), aux)
+}
+
+// VerifyAuto verifies a signature made by SignAuto.
+func VerifyAuto(publicKey [32]byte, data []byte, signature [64]byte) (bool, error) {
+	return Verify(publicKey, autoMessage(data), signature)
+}
+
+func autoMessage(data []byte) [32]byte {
+	if len(data) == 32 {
+		message := [32]byte{}
+		copy(message[:], data)
+		return message
+	}
+	return hashMessage(data)
+}
+
+// SignLE signs message with its bytes in reverse order, for peers that hand
+// their digests over little-endian. It is only a compatibility shim and not
+// part of BIP340: the signature is one of the reversed message, so it only
+// verifies with VerifyLE, or with Verify given the reversed message.
+func SignLE(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
+	return Sign(privateKey, reverseMessage(message), aux)
+}
+
+// VerifyLE verifies a signature made by SignLE, see there.
+func VerifyLE(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, error) {
+	return Verify(publicKey, reverseMessage(message), signature)
+}
+
+func reverseMessage(message [32]byte) [32]byte {
+	for i, j := 0, len(message)-1; i < j; i, j = i+1, j-1 {
+		message[i], message[j] = message[j], message[i]
+	}
+	return message
+}
+
+// SignWithTag signs data of any length like SignMessage, but hashed with the
+// given tag instead of MessageTag, which binds the signature to one protocol:
+// it can't be checked against the same data under any other tag.
+// SignWithTag(privateKey, MessageTag, data, aux) is the same as SignMessage.
+func SignWithTag(privateKey *big.Int, tag string, data []byte, aux []byte) ([64]byte, error) {
+	return Sign(privateKey, taggedHash(tag, data), aux)
+}
+
+// VerifyWithTag verifies a signature made by SignWithTag with the same tag.
+func VerifyWithTag(publicKey [32]byte, tag string, data []byte, signature [64]byte) (bool, error) {
+	return Verify(publicKey, taggedHash(tag, data), signature)
+}
+
+func hashMessage(data []byte) [32]byte {
+	h := newMessageHash()
+	h.Write(data)
+	digest := [32]byte{}
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+// newMessageHash returns a sha256 hash already fed with the tag prefix, so the
+// data can be written to it as it comes.
+func newMessageHash() hash.Hash {
+	tagHash := sha256.Sum256([]byte(MessageTag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	return h
+}
@@ -0,0 +1,114 @@
+package schnorr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+
+	for _, data := range [][]byte{nil, []byte("hello"), bytes.Repeat([]byte{7}, 32), bytes.Repeat([]byte{1}, 1000)} {
+		signature, err := SignMessage(d, data, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from SignMessage: %v", err)
+		}
+		if ok, err := VerifyMessage(publicKey, data, signature); !ok {
+			t.Fatalf("VerifyMessage of %d bytes failed: %v", len(data), err)
+		}
+		if ok, _ := VerifyMessage(publicKey, append(data, 0), signature); ok {
+			t.Fatalf("VerifyMessage succeeded for different data")
+		}
+	}
+
+	// a 32 byte message signed directly is not the same as signed as data.
+	var message [32]byte
+	signature, _ := Sign(d, message, nil)
+	if ok, _ := VerifyMessage(publicKey, message[:], signature); ok {
+		t.Fatalf("VerifyMessage accepted a signature made with Sign")
+	}
+	if digest := hashMessage(nil); digest != taggedHash(MessageTag) {
+		t.Fatalf("hashMessage is not the tagged hash")
+	}
+}
+
+func TestSignAuto(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+
+	for _, n := range []int{0, 31, 32, 33} {
+		data := bytes.Repeat([]byte{byte(n)}, n)
+		signature, err := SignAuto(d, data, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from SignAuto: %v", err)
+		}
+		if ok, err := VerifyAuto(publicKey, data, signature); !ok {
+			t.Fatalf("VerifyAuto of %d bytes failed: %v", n, err)
+		}
+		if ok, _ := VerifyAuto(publicKey, append(data, 0), signature); ok {
+			t.Fatalf("VerifyAuto of %d bytes succeeded with a byte more", n)
+		}
+
+		// 32 bytes are signed directly, anything else is hashed.
+		var expected [64]byte
+		if n == 32 {
+			var message [32]byte
+			copy(message[:], data)
+			expected, _ = Sign(d, message, nil)
+		} else {
+			expected, _ = SignMessage(d, data, nil)
+		}
+		if signature != expected {
+			t.Fatalf("SignAuto of %d bytes = %x, want %x", n, signature, expected)
+		}
+	}
+}
+
+func TestSignLE(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+
+	signature, err := SignLE(d, message, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from SignLE: %v", err)
+	}
+	if ok, err := VerifyLE(publicKey, message, signature); !ok {
+		t.Fatalf("VerifyLE of SignLE signature failed: %v", err)
+	}
+	if ok, _ := Verify(publicKey, message, signature); ok {
+		t.Fatalf("Verify of SignLE signature succeeded")
+	}
+
+	reversed := message
+	for i := range reversed {
+		reversed[i] = message[len(message)-1-i]
+	}
+	if ok, err := Verify(publicKey, reversed, signature); !ok {
+		t.Fatalf("Verify of SignLE signature with the reversed message failed: %v", err)
+	}
+}
+
+func TestSignWithTag(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	data := []byte("transfer 10 coins")
+
+	signature, err := SignWithTag(d, "payments/v1", data, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from SignWithTag: %v", err)
+	}
+	if ok, err := VerifyWithTag(publicKey, "payments/v1", data, signature); !ok {
+		t.Fatalf("VerifyWithTag failed: %v", err)
+	}
+	for _, tag := range []string{"payments/v2", "", MessageTag} {
+		if ok, _ := VerifyWithTag(publicKey, tag, data, signature); ok {
+			t.Fatalf("VerifyWithTag succeeded with tag %q", tag)
+		}
+	}
+	if other, _ := SignWithTag(d, "payments/v2", data, nil); other == signature {
+		t.Fatalf("SignWithTag gives the same signature for different tags")
+	}
+
+	expected, _ := SignMessage(d, data, nil)
+	if observed, _ := SignWithTag(d, MessageTag, data, nil); observed != expected {
+		t.Fatalf("SignWithTag(MessageTag) = %x, want SignMessage = %x", observed, expected)
+	}
+}
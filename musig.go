@@ -0,0 +1,374 @@
+package schnorr
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// MuSig lets n signers produce a single signature under one aggregate public
+// key. The result is an ordinary BIP340 signature: Verify can't tell it apart
+// from one made by a single signer. This follows MuSig2 (two nonces per signer,
+// so it only takes one round once the nonces are exchanged), adapted to x-only
+// keys.
+//
+// Every signer:
+//
+//  1. calls GenerateNonce and sends the public nonce to the others,
+//  2. calls AggregateNonces with everybody's public nonces,
+//  3. calls PartialSign and sends the partial signature to whoever combines
+//     them with CombinePartialSignatures.
+//
+// https://eprint.iacr.org/2020/1261
+
+// AggregatePublicKeys returns the aggregate key of the signers, which is what
+// the combined signature verifies against. The same keys in a different order
+// give a different aggregate key, so all the signers must agree on the order.
+//
+// Every key is multiplied by a coefficient derived from all of them, so a
+// signer can't pick a key that cancels out the others' keys.
+func AggregatePublicKeys(publicKeys [][32]byte) ([32]byte, error) {
+	aggregateKey := [32]byte{}
+	Qx, _, _, err := keyAgg(publicKeys)
+	if err != nil {
+		return aggregateKey, err
+	}
+	intToByteInto(aggregateKey[:], Qx)
+	return aggregateKey, nil
+}
+
+// AggregatePublicKeysSecure is AggregatePublicKeys for keys from parties that
+// aren't trusted, which must also prove that they hold their private keys with
+// ProvePossession. It fails unless every proof verifies against its key, with
+// the proofs in the same order as the keys.
+//
+// The coefficients of AggregatePublicKeys already stop a rogue key from
+// cancelling out the others, but not somebody from registering a key that
+// belongs to someone else, or that has no known private key at all.
+func AggregatePublicKeysSecure(publicKeys [][32]byte, proofs [][64]byte) ([32]byte, error) {
+	if len(proofs) != len(publicKeys) {
+		return [32]byte{}, errors.New("all parameters must be an array with the same length")
+	}
+	for i := range publicKeys {
+		if ok, err := VerifyPossession(publicKeys[i], proofs[i]); !ok {
+			return [32]byte{}, fmt.Errorf("proof %d: %w", i, err)
+		}
+	}
+	return AggregatePublicKeys(publicKeys)
+}
+
+// ProvePossession returns a proof that whoever made it holds the private key,
+// for AggregatePublicKeysSecure. It is a signature of a hash of the public key
+// that can't be mistaken for a signature of anything else.
+func ProvePossession(privateKey *big.Int) ([64]byte, error) {
+	publicKey, err := GetPublicKey(privateKey)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	return Sign(privateKey, possessionMessage(publicKey), nil)
+}
+
+// VerifyPossession verifies a proof made by ProvePossession.
+func VerifyPossession(publicKey [32]byte, proof [64]byte) (bool, error) {
+	return Verify(publicKey, possessionMessage(publicKey), proof)
+}
+
+func possessionMessage(publicKey [32]byte) [32]byte {
+	return taggedHash("schnorr/possession", publicKey[:])
+}
+
+// GenerateNonce returns a secret nonce and the public nonce to send to the
+// other signers. Calling with a nil random will cause the function to use
+// crypto/rand.
+//
+// WARNING: a secret nonce must be passed to PartialSign only once. Anyone who
+// sees two partial signatures made with the same nonce can compute the private
+// key from them. PartialSign clears the nonce it is given to help with that,
+// but can't do anything about copies of it.
+func GenerateNonce(random io.Reader) (secNonce [64]byte, pubNonce [64]byte, err error) {
+	for i := 0; i < 2; i++ {
+		k, err := randomScalar(random)
+		if err != nil {
+			return secNonce, pubNonce, err
+		}
+
+		// only the x coordinate is sent, so pick the k for the even y.
+		Rx, Ry := baseMult(k)
+		if Ry.Bit(0) == 1 {
+			k.Sub(Curve.N, k)
+		}
+		intToByteInto(secNonce[32*i:], k)
+		intToByteInto(pubNonce[32*i:], Rx)
+	}
+	return secNonce, pubNonce, nil
+}
+
+// AggregateNonces adds up the public nonces of all the signers. The result is
+// two points in 33 byte compressed form, a point at infinity being all zeros.
+func AggregateNonces(pubNonces [][64]byte) ([66]byte, error) {
+	aggNonce := [66]byte{}
+	if len(pubNonces) == 0 {
+		return aggNonce, errors.New("pubNonces must be an array with one or more elements")
+	}
+
+	for j := 0; j < 2; j++ {
+		var R jacobianPoint
+		for i, pubNonce := range pubNonces {
+			Ri, ok := liftX(new(big.Int).SetBytes(pubNonce[32*j : 32*(j+1)]))
+			if !ok {
+				return aggNonce, fmt.Errorf("nonce %d is not a valid point", i)
+			}
+			R.addMixed(&R, &Ri)
+		}
+		copy(aggNonce[33*j:], compressPoint(&R))
+	}
+	return aggNonce, nil
+}
+
+// NonceCommitment returns the hash of a public nonce that a signer sends to the
+// others before any of them reveals its nonce. Having everybody commit first
+// keeps a signer from picking its nonce after seeing all the others, which is
+// what Wagner's attack on concurrent sessions needs. Once every commitment is
+// in, the nonces are revealed and AggregateCommittedNonces checks them.
+func NonceCommitment(pubNonce [64]byte) [32]byte {
+	return taggedHash("schnorr/nonce commitment", pubNonce[:])
+}
+
+// AggregateCommittedNonces is AggregateNonces for nonces that were committed to
+// with NonceCommitment, pubNonces[i] being checked against commitments[i]. The
+// round must be aborted if it returns ErrNonceCommitment, which is wrapped to
+// say which signer cheated.
+func AggregateCommittedNonces(pubNonces [][64]byte, commitments [][32]byte) ([66]byte, error) {
+	if len(pubNonces) != len(commitments) {
+		return [66]byte{}, errors.New("pubNonces and commitments must have the same length")
+	}
+	for i := range pubNonces {
+		commitment := NonceCommitment(pubNonces[i])
+		if subtle.ConstantTimeCompare(commitment[:], commitments[i][:]) != 1 {
+			return [66]byte{}, fmt.Errorf("nonce %d: %w", i, ErrNonceCommitment)
+		}
+	}
+	return AggregateNonces(pubNonces)
+}
+
+// PartialSign makes this signer's share of the signature of message, with the
+// private key and secret nonce of the signer and the public keys, in the same
+// order, given to AggregatePublicKeys.
+//
+// secNonce is overwritten with zeros as soon as it is read, even if signing
+// fails, so that it can't be used twice: passing it again returns
+// ErrNonceOutOfRange. Like Sign, PartialSign also clears the secrets it
+// computes on the way.
+func PartialSign(privateKey *big.Int, secNonce *[64]byte, publicKeys [][32]byte, aggNonce [66]byte, message [32]byte) ([32]byte, error) {
+	partialSig := [32]byte{}
+	k1, err1 := ParseScalar(secNonce[:32])
+	k2, err2 := ParseScalar(secNonce[32:])
+	zeroBytes(secNonce[:])
+	if err1 != nil || err2 != nil {
+		return partialSig, ErrNonceOutOfRange
+	}
+	defer zeroInt(k1)
+	defer zeroInt(k2)
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return partialSig, ErrKeyOutOfRange
+	}
+
+	s, err := newMuSigSession(publicKeys, aggNonce, message)
+	if err != nil {
+		return partialSig, err
+	}
+
+	// the keys were lifted with an even y, so do the same as Sign does with d.
+	Px, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	defer zeroInt(d)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+	a := s.coefficient(Px)
+	if a == nil {
+		return partialSig, errors.New("the private key is not one of the signers")
+	}
+	if s.negQ {
+		d.Sub(Curve.N, d)
+	}
+	if s.negR {
+		k1.Sub(Curve.N, k1)
+		k2.Sub(Curve.N, k2)
+	}
+
+	// k1 + b*k2 + e*a*d
+	k2.Mul(k2, s.b)
+	d.Mul(d, a)
+	d.Mul(d, s.e)
+	k1.Add(k1, k2)
+	k1.Add(k1, d)
+	intToByteInto(partialSig[:], k1.Mod(k1, Curve.N))
+	return partialSig, nil
+}
+
+// CombinePartialSignatures adds up the partial signatures of all the signers,
+// returning a 64 byte signature of message that verifies against the aggregate
+// public key.
+func CombinePartialSignatures(publicKeys [][32]byte, aggNonce [66]byte, message [32]byte, partialSigs [][32]byte) ([64]byte, error) {
+	sig := [64]byte{}
+	if len(partialSigs) != len(publicKeys) {
+		return sig, errors.New("all parameters must be an array with the same length")
+	}
+
+	s, err := newMuSigSession(publicKeys, aggNonce, message)
+	if err != nil {
+		return sig, err
+	}
+
+	sum := new(big.Int)
+	for i, partialSig := range partialSigs {
+		si, err := ParseScalar(partialSig[:])
+		if err != nil {
+			return sig, fmt.Errorf("partial signature %d: %w", i, err)
+		}
+		sum.Add(sum, si)
+	}
+
+	copy(sig[:32], s.rX)
+	intToByteInto(sig[32:], sum.Mod(sum, Curve.N))
+	return sig, nil
+}
+
+// keyAgg returns the aggregate key Q and the coefficient of every key.
+func keyAgg(publicKeys [][32]byte) (Qx, Qy *big.Int, coefficients []*big.Int, err error) {
+	if len(publicKeys) == 0 {
+		return nil, nil, nil, errors.New("publicKeys must be an array with one or more elements")
+	}
+
+	list := make([][]byte, len(publicKeys))
+	for i := range publicKeys {
+		list[i] = publicKeys[i][:]
+	}
+	L := taggedHash("KeyAgg list", list...)
+
+	// the same key twice would need its coefficients added up by whoever holds
+	// it, and two partial signatures from it, so it is not allowed.
+	seen := make(map[[32]byte]int, len(publicKeys))
+	points := make([]affinePoint, len(publicKeys))
+	coefficients = make([]*big.Int, len(publicKeys))
+	for i := range publicKeys {
+		if j, ok := seen[publicKeys[i]]; ok {
+			return nil, nil, nil, fmt.Errorf("public keys %d and %d are the same", j, i)
+		}
+		seen[publicKeys[i]] = i
+		P, ok := liftX(new(big.Int).SetBytes(publicKeys[i][:]))
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("public key %d: %w", i, ErrInvalidPublicKey)
+		}
+		points[i] = P
+		coefficients[i] = hashToScalar(taggedHash("KeyAgg coefficient", L[:], publicKeys[i][:]))
+	}
+
+	Q := multiScalarMult(points, coefficients)
+	if Q.isInfinity() {
+		return nil, nil, nil, ErrPointAtInfinity
+	}
+	Qx, Qy = Q.affine()
+	return Qx, Qy, coefficients, nil
+}
+
+// muSigSession holds what PartialSign and CombinePartialSignatures both derive
+// from the keys, the aggregate nonce and the message.
+type muSigSession struct {
+	publicKeys   [][32]byte
+	coefficients []*big.Int
+	// negQ and negR tell whether Q and R have an odd y, so the secrets behind
+	// them must be negated.
+	negQ, negR bool
+	rX         []byte
+	b, e       *big.Int
+}
+
+func newMuSigSession(publicKeys [][32]byte, aggNonce [66]byte, message [32]byte) (*muSigSession, error) {
+	Qx, Qy, coefficients, err := keyAgg(publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	R1, ok1 := decompressPoint(aggNonce[:33])
+	R2, ok2 := decompressPoint(aggNonce[33:])
+	if !ok1 || !ok2 {
+		return nil, errors.New("aggregate nonce is not valid")
+	}
+
+	b := hashToScalar(taggedHash("MuSig/noncecoef", aggNonce[:], intToByte(Qx), message[:]))
+
+	// R = R1 + b*R2, or G in the unlikely case that is infinity, which is
+	// harmless as nobody can make it happen on purpose.
+	var R jacobianPoint
+	if x, y := R2.affine(); x != nil {
+		R = multiScalarMult([]affinePoint{newAffinePoint(x, y)}, []*big.Int{b})
+	}
+	R.add(&R, &R1)
+	if R.isInfinity() {
+		G := newAffinePoint(Curve.Gx, Curve.Gy)
+		R.setAffine(&G)
+	}
+	Rx, Ry := R.affine()
+
+	rX := intToByte(Rx)
+	return &muSigSession{
+		publicKeys:   publicKeys,
+		coefficients: coefficients,
+		negQ:         Qy.Bit(0) == 1,
+		negR:         Ry.Bit(0) == 1,
+		rX:           rX,
+		b:            b,
+		e:            getE(Qx, Qy, rX, message),
+	}, nil
+}
+
+// coefficient returns the coefficient of the public key with x coordinate Px,
+// or nil if it isn't one of the signers.
+func (s *muSigSession) coefficient(Px *big.Int) *big.Int {
+	pk := intToByte(Px)
+	for i := range s.publicKeys {
+		if string(s.publicKeys[i][:]) == string(pk) {
+			return s.coefficients[i]
+		}
+	}
+	return nil
+}
+
+// compressPoint encodes p as its x coordinate prefixed by 2 for an even y or 3
+// for an odd one, or as 33 zero bytes for the point at infinity.
+func compressPoint(p *jacobianPoint) []byte {
+	b := make([]byte, 33)
+	if x, y := p.affine(); x != nil {
+		b[0] = 2 + byte(y.Bit(0))
+		intToByteInto(b[1:], x)
+	}
+	return b
+}
+
+// decompressPoint is the reverse of compressPoint.
+func decompressPoint(b []byte) (p jacobianPoint, ok bool) {
+	if b[0] == 0 {
+		for _, c := range b {
+			if c != 0 {
+				return p, false
+			}
+		}
+		return p, true
+	}
+	if b[0] != 2 && b[0] != 3 {
+		return p, false
+	}
+
+	a, ok := liftX(new(big.Int).SetBytes(b[1:]))
+	if !ok {
+		return p, false
+	}
+	if b[0] == 3 {
+		a.y.neg(&a.y)
+	}
+	return *p.setAffine(&a), true
+}
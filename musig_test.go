@@ -0,0 +1,205 @@
+package schnorr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMuSig(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "many signers, one signature")
+
+	// enough signers that some keys, nonces and the aggregates have an odd y.
+	n := 8
+	privateKeys := make([]*PrivateKey, n)
+	publicKeys := make([][32]byte, n)
+	for i := range privateKeys {
+		d, pk, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		privateKeys[i], publicKeys[i] = &PrivateKey{D: d}, pk
+	}
+
+	aggregateKey, err := AggregatePublicKeys(publicKeys)
+	if err != nil {
+		t.Fatalf("Unexpected error from AggregatePublicKeys: %v", err)
+	}
+	if swapped, _ := AggregatePublicKeys(append([][32]byte{publicKeys[1], publicKeys[0]}, publicKeys[2:]...)); swapped == aggregateKey {
+		t.Fatalf("AggregatePublicKeys gives the same key for another order")
+	}
+
+	for round := 0; round < 4; round++ {
+		secNonces := make([][64]byte, n)
+		pubNonces := make([][64]byte, n)
+		for i := range secNonces {
+			if secNonces[i], pubNonces[i], err = GenerateNonce(nil); err != nil {
+				t.Fatalf("Unexpected error from GenerateNonce: %v", err)
+			}
+		}
+		aggNonce, err := AggregateNonces(pubNonces)
+		if err != nil {
+			t.Fatalf("Unexpected error from AggregateNonces: %v", err)
+		}
+
+		partialSigs := make([][32]byte, n)
+		for i := range partialSigs {
+			if partialSigs[i], err = PartialSign(privateKeys[i].D, &secNonces[i], publicKeys, aggNonce, message); err != nil {
+				t.Fatalf("Unexpected error from PartialSign: %v", err)
+			}
+		}
+		signature, err := CombinePartialSignatures(publicKeys, aggNonce, message, partialSigs)
+		if err != nil {
+			t.Fatalf("Unexpected error from CombinePartialSignatures: %v", err)
+		}
+		if ok, err := Verify(aggregateKey, message, signature); !ok {
+			t.Fatalf("Verify of the combined signature failed: %v", err)
+		}
+
+		// one signer missing.
+		partialSigs[0] = [32]byte{}
+		signature, _ = CombinePartialSignatures(publicKeys, aggNonce, message, partialSigs)
+		if ok, _ := Verify(aggregateKey, message, signature); ok {
+			t.Fatalf("Verify succeeded without one of the partial signatures")
+		}
+	}
+
+	// somebody who isn't a signer.
+	d, _, _ := GenerateKeyPair(nil)
+	secNonce, pubNonce, _ := GenerateNonce(nil)
+	aggNonce, _ := AggregateNonces([][64]byte{pubNonce})
+	if _, err := PartialSign(d, &secNonce, publicKeys, aggNonce, message); err == nil {
+		t.Fatalf("PartialSign succeeded for a key that is not one of the signers")
+	}
+}
+
+func TestPartialSignClearsNonce(t *testing.T) {
+	var message [32]byte
+	d, publicKey, _ := GenerateKeyPair(nil)
+	publicKeys := [][32]byte{publicKey}
+	secNonce, pubNonce, _ := GenerateNonce(nil)
+	aggNonce, _ := AggregateNonces([][64]byte{pubNonce})
+
+	if _, err := PartialSign(d, &secNonce, publicKeys, aggNonce, message); err != nil {
+		t.Fatalf("Unexpected error from PartialSign: %v", err)
+	}
+	if secNonce != [64]byte{} {
+		t.Fatalf("PartialSign left the secret nonce as it was")
+	}
+	if _, err := PartialSign(d, &secNonce, publicKeys, aggNonce, message); err != ErrNonceOutOfRange {
+		t.Fatalf("PartialSign with a used nonce = %v, want %v", err, ErrNonceOutOfRange)
+	}
+}
+
+func TestDuplicatePublicKeys(t *testing.T) {
+	_, a, _ := GenerateKeyPair(nil)
+	_, b, _ := GenerateKeyPair(nil)
+	if _, err := AggregatePublicKeys([][32]byte{a, b, a}); err == nil {
+		t.Fatalf("AggregatePublicKeys accepted the same key twice")
+	}
+	var aggNonce [66]byte
+	var message [32]byte
+	if _, err := CombinePartialSignatures([][32]byte{b, b}, aggNonce, message, make([][32]byte, 2)); err == nil {
+		t.Fatalf("CombinePartialSignatures accepted the same key twice")
+	}
+}
+
+func TestAggregateNonces(t *testing.T) {
+	_, pubNonce, err := GenerateNonce(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from GenerateNonce: %v", err)
+	}
+
+	// a nonce and its negation add up to infinity, which must round trip.
+	aggNonce, err := AggregateNonces([][64]byte{pubNonce})
+	if err != nil {
+		t.Fatalf("Unexpected error from AggregateNonces: %v", err)
+	}
+	R, ok := decompressPoint(aggNonce[:33])
+	if !ok || R.isInfinity() {
+		t.Fatalf("decompressPoint(%x) failed", aggNonce[:33])
+	}
+	neg := R
+	neg.y.neg(&neg.y)
+	R.add(&R, &neg)
+	if encoded := compressPoint(&R); string(encoded) != string(make([]byte, 33)) {
+		t.Fatalf("compressPoint(infinity) = %x, want zeros", encoded)
+	}
+	if _, ok := decompressPoint(make([]byte, 33)); !ok {
+		t.Fatalf("decompressPoint(zeros) failed")
+	}
+
+	bad := pubNonce
+	copy(bad[:32], intToByte(Curve.P))
+	if _, err := AggregateNonces([][64]byte{bad}); err == nil {
+		t.Fatalf("AggregateNonces accepted a nonce that is not a point")
+	}
+}
+
+func TestAggregateCommittedNonces(t *testing.T) {
+	var pubNonces [][64]byte
+	var commitments [][32]byte
+	for i := 0; i < 3; i++ {
+		_, pubNonce, err := GenerateNonce(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateNonce: %v", err)
+		}
+		pubNonces = append(pubNonces, pubNonce)
+		commitments = append(commitments, NonceCommitment(pubNonce))
+	}
+
+	aggNonce, err := AggregateCommittedNonces(pubNonces, commitments)
+	if err != nil {
+		t.Fatalf("Unexpected error from AggregateCommittedNonces: %v", err)
+	}
+	if expected, _ := AggregateNonces(pubNonces); aggNonce != expected {
+		t.Fatalf("AggregateCommittedNonces = %x, want %x", aggNonce, expected)
+	}
+
+	// the last signer reveals another nonce than the one it committed to.
+	_, other, _ := GenerateNonce(nil)
+	cheating := append([][64]byte{}, pubNonces...)
+	cheating[2] = other
+	if _, err := AggregateCommittedNonces(cheating, commitments); !errors.Is(err, ErrNonceCommitment) {
+		t.Fatalf("AggregateCommittedNonces with a mismatched nonce = %v, want %v", err, ErrNonceCommitment)
+	}
+
+	if _, err := AggregateCommittedNonces(pubNonces, commitments[:2]); err == nil {
+		t.Fatalf("AggregateCommittedNonces accepted a missing commitment")
+	}
+}
+
+func TestAggregatePublicKeysSecure(t *testing.T) {
+	publicKeys := make([][32]byte, 3)
+	proofs := make([][64]byte, 3)
+	for i := range publicKeys {
+		d, publicKey, _ := GenerateKeyPair(nil)
+		proof, err := ProvePossession(d)
+		if err != nil {
+			t.Fatalf("Unexpected error from ProvePossession: %v", err)
+		}
+		publicKeys[i], proofs[i] = publicKey, proof
+	}
+
+	aggregateKey, err := AggregatePublicKeysSecure(publicKeys, proofs)
+	if err != nil {
+		t.Fatalf("Unexpected error from AggregatePublicKeysSecure: %v", err)
+	}
+	if expected, _ := AggregatePublicKeys(publicKeys); aggregateKey != expected {
+		t.Fatalf("AggregatePublicKeysSecure = %x, want %x", aggregateKey, expected)
+	}
+
+	// a proof for another key, or an ordinary signature, is no proof.
+	swapped := [][64]byte{proofs[1], proofs[0], proofs[2]}
+	if _, err := AggregatePublicKeysSecure(publicKeys, swapped); err == nil {
+		t.Fatalf("AggregatePublicKeysSecure succeeded with the proofs swapped")
+	}
+	d, publicKey, _ := GenerateKeyPair(nil)
+	signature, _ := Sign(d, publicKey, nil)
+	if ok, _ := VerifyPossession(publicKey, signature); ok {
+		t.Fatalf("VerifyPossession accepted a signature of the public key itself")
+	}
+	if _, err := AggregatePublicKeysSecure(publicKeys, proofs[:2]); err == nil {
+		t.Fatalf("AggregatePublicKeysSecure succeeded with a missing proof")
+	}
+}
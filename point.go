@@ -0,0 +1,585 @@
+package schnorr
+
+import (
+	"context"
+	"math/big"
+	"sync"
+)
+
+// affinePoint is a point on the curve with both coordinates as field elements.
+type affinePoint struct {
+	x, y fieldVal
+}
+
+// jacobianPoint is a point in jacobian coordinates, (x, y) = (X/Z², Y/Z³).
+// Z = 0 is the point at infinity.
+type jacobianPoint struct {
+	x, y, z fieldVal
+}
+
+// generator is G as an affinePoint.
+var generator = newAffinePoint(Curve.Gx, Curve.Gy)
+
+func newAffinePoint(x, y *big.Int) affinePoint {
+	var p affinePoint
+	p.x.setInt(x)
+	p.y.setInt(y)
+	return p
+}
+
+// liftX returns the point with the given x coordinate and an even y, as in
+// BIP340's lift_x. ok is false if there is no such point. Only the range check
+// on x branches: the square root is always computed in full and the even root
+// is picked with a cmov.
+func liftX(x *big.Int) (p affinePoint, ok bool) {
+	if x.Cmp(Curve.P) >= 0 {
+		return p, false
+	}
+	p.x.setInt(x)
+	return p, p.liftY()
+}
+
+// liftXBytes is liftX for x given as 32 big-endian bytes, which saves going
+// through a big.Int.
+func liftXBytes(b []byte) (p affinePoint, ok bool) {
+	// setBytes reduces x modulo p, it must have been below p already.
+	var check [32]byte
+	p.x.setBytes(b)
+	p.x.putBytes(check[:])
+	if string(check[:]) != string(b[:32]) {
+		return p, false
+	}
+	return p, p.liftY()
+}
+
+// liftY sets y to the even square root of x^3 + 7, telling whether there is
+// one.
+func (p *affinePoint) liftY() bool {
+	var ySq, negY fieldVal
+	seven := fieldVal{7, 0, 0, 0}
+	ySq.square(&p.x)
+	ySq.mul(&ySq, &p.x)
+	ySq.add(&ySq, &seven)
+	ok := p.y.sqrt(&ySq)
+	negY.neg(&p.y)
+	p.y.cmov(&negY, p.y[0]&1)
+	return ok
+}
+
+// isOnCurve tells whether y^2 = x^3 + 7.
+func (p *affinePoint) isOnCurve() bool {
+	var lhs, rhs fieldVal
+	seven := fieldVal{7, 0, 0, 0}
+	lhs.square(&p.y)
+	rhs.square(&p.x)
+	rhs.mul(&rhs, &p.x)
+	rhs.add(&rhs, &seven)
+	return lhs.equals(&rhs)
+}
+
+func (a *affinePoint) cmov(b *affinePoint, flag uint64) {
+	a.x.cmov(&b.x, flag)
+	a.y.cmov(&b.y, flag)
+}
+
+func (p *jacobianPoint) cmov(q *jacobianPoint, flag uint64) {
+	p.x.cmov(&q.x, flag)
+	p.y.cmov(&q.y, flag)
+	p.z.cmov(&q.z, flag)
+}
+
+func (p *jacobianPoint) isInfinity() bool {
+	return p.z.isZero()
+}
+
+func (p *jacobianPoint) setAffine(a *affinePoint) *jacobianPoint {
+	p.x, p.y, p.z = a.x, a.y, fieldOne
+	return p
+}
+
+// affine converts p back to big.Int coordinates. Returns nil, nil for the point
+// at infinity.
+func (p *jacobianPoint) affine() (x, y *big.Int) {
+	if p.isInfinity() {
+		return nil, nil
+	}
+	a := p.toAffine()
+	return a.x.int(), a.y.int()
+}
+
+// toAffine is affine without going through big.Int. p must not be the point
+// at infinity.
+func (p *jacobianPoint) toAffine() (a affinePoint) {
+	var zInv, zInv2 fieldVal
+	zInv.inverse(&p.z)
+	zInv2.square(&zInv)
+	a.x.mul(&p.x, &zInv2)
+	zInv2.mul(&zInv2, &zInv)
+	a.y.mul(&p.y, &zInv2)
+	return a
+}
+
+// double sets p = 2a.
+// https://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+func (p *jacobianPoint) double(a *jacobianPoint) *jacobianPoint {
+	var A, B, C, D, E, F, t fieldVal
+	A.square(&a.x)
+	B.square(&a.y)
+	C.square(&B)
+	D.add(&a.x, &B)
+	D.square(&D)
+	D.sub(&D, &A)
+	D.sub(&D, &C)
+	D.add(&D, &D)
+	E.add(&A, &A)
+	E.add(&E, &A)
+	F.square(&E)
+
+	// z has to be computed first in case p and a are the same.
+	p.z.mul(&a.y, &a.z)
+	p.z.add(&p.z, &p.z)
+
+	p.x.sub(&F, &D)
+	p.x.sub(&p.x, &D)
+
+	C.add(&C, &C)
+	C.add(&C, &C)
+	C.add(&C, &C)
+	t.sub(&D, &p.x)
+	p.y.mul(&E, &t)
+	p.y.sub(&p.y, &C)
+	return p
+}
+
+// addMixed sets p = a + b, where b is given in affine coordinates.
+func (p *jacobianPoint) addMixed(a *jacobianPoint, b *affinePoint) *jacobianPoint {
+	if a.isInfinity() {
+		return p.setAffine(b)
+	}
+
+	var sum jacobianPoint
+	if hZero, rZero := sum.addMixedFormula(a, b); hZero == 1 {
+		if rZero == 1 {
+			return p.double(a)
+		}
+		*p = jacobianPoint{}
+		return p
+	}
+	*p = sum
+	return p
+}
+
+// addMixedConst is addMixed without any branches, for points that depend on
+// secrets. It does a doubling on top of the addition every time, in case a
+// and b are the same point.
+func (p *jacobianPoint) addMixedConst(a *jacobianPoint, b *affinePoint) *jacobianPoint {
+	var sum, dbl, start jacobianPoint
+	hZero, rZero := sum.addMixedFormula(a, b)
+	dbl.double(a)
+	start.setAffine(b)
+
+	// a = -b already comes out as infinity, with a z of zero.
+	infinity := a.z.isZeroFlag()
+	sum.cmov(&dbl, hZero&rZero&^infinity)
+	sum.cmov(&start, infinity)
+	*p = sum
+	return p
+}
+
+// addMixedFormula sets p = a + b with the general formula, which is only right
+// when a is not infinity and a != ±b. It returns whether H and r are zero: H is
+// zero when a = ±b, and r too when a = b. p must not be a.
+// https://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-madd-2007-bl
+func (p *jacobianPoint) addMixedFormula(a *jacobianPoint, b *affinePoint) (hZero, rZero uint64) {
+	var Z1Z1, U2, S2, H, HH, I, J, r, V, t fieldVal
+	Z1Z1.square(&a.z)
+	U2.mul(&b.x, &Z1Z1)
+	S2.mul(&b.y, &a.z)
+	S2.mul(&S2, &Z1Z1)
+	H.sub(&U2, &a.x)
+	r.sub(&S2, &a.y)
+	hZero, rZero = H.isZeroFlag(), r.isZeroFlag()
+	r.add(&r, &r)
+	HH.square(&H)
+	I.add(&HH, &HH)
+	I.add(&I, &I)
+	J.mul(&H, &I)
+	V.mul(&a.x, &I)
+
+	t.add(&a.z, &H)
+	t.square(&t)
+	t.sub(&t, &Z1Z1)
+	p.z.sub(&t, &HH)
+
+	J2 := J
+	J2.mul(&J2, &a.y)
+	J2.add(&J2, &J2)
+
+	p.x.square(&r)
+	p.x.sub(&p.x, &J)
+	p.x.sub(&p.x, &V)
+	p.x.sub(&p.x, &V)
+
+	t.sub(&V, &p.x)
+	p.y.mul(&r, &t)
+	p.y.sub(&p.y, &J2)
+	return hZero, rZero
+}
+
+// add sets p = a + b.
+// https://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+func (p *jacobianPoint) add(a, b *jacobianPoint) *jacobianPoint {
+	if a.isInfinity() {
+		*p = *b
+		return p
+	}
+	if b.isInfinity() {
+		*p = *a
+		return p
+	}
+
+	var Z1Z1, Z2Z2, U1, U2, S1, S2, H, I, J, r, V, t fieldVal
+	Z1Z1.square(&a.z)
+	Z2Z2.square(&b.z)
+	U1.mul(&a.x, &Z2Z2)
+	U2.mul(&b.x, &Z1Z1)
+	S1.mul(&a.y, &b.z)
+	S1.mul(&S1, &Z2Z2)
+	S2.mul(&b.y, &a.z)
+	S2.mul(&S2, &Z1Z1)
+	H.sub(&U2, &U1)
+	r.sub(&S2, &S1)
+	if H.isZero() {
+		if r.isZero() {
+			return p.double(a)
+		}
+		*p = jacobianPoint{}
+		return p
+	}
+	r.add(&r, &r)
+	I.add(&H, &H)
+	I.square(&I)
+	J.mul(&H, &I)
+	V.mul(&U1, &I)
+
+	t.add(&a.z, &b.z)
+	t.square(&t)
+	t.sub(&t, &Z1Z1)
+	t.sub(&t, &Z2Z2)
+	p.z.mul(&t, &H)
+
+	S1.mul(&S1, &J)
+	S1.add(&S1, &S1)
+
+	p.x.square(&r)
+	p.x.sub(&p.x, &J)
+	p.x.sub(&p.x, &V)
+	p.x.sub(&p.x, &V)
+
+	t.sub(&V, &p.x)
+	p.y.mul(&r, &t)
+	p.y.sub(&p.y, &S1)
+	return p
+}
+
+// multiScalarMult computes the sum of scalars[i] * points[i] using the bucket
+// method (Pippenger), which is much cheaper than doing each multiplication on
+// its own once there are more than a handful of points.
+func multiScalarMult(points []affinePoint, scalars []*big.Int) jacobianPoint {
+	result, _ := multiScalarMultContext(context.Background(), points, scalars)
+	return result
+}
+
+// multiScalarMultContext is multiScalarMult, but gives up with ctx.Err() as
+// soon as ctx is done. It checks once per window, so about 256 / msmWindow
+// times, each window taking one point addition per point.
+func multiScalarMultContext(ctx context.Context, points []affinePoint, scalars []*big.Int) (jacobianPoint, error) {
+	var result jacobianPoint
+	if len(points) == 0 {
+		return result, nil
+	}
+
+	// on the stack for the few points of Verify.
+	var digitsBuf [straussPoints][4]uint64
+	digits := digitsBuf[:0]
+	for _, s := range scalars {
+		digits = append(digits, scalarLimbs(s))
+	}
+	if len(points) < straussPoints {
+		return straussMult(ctx, points, digits)
+	}
+
+	c := msmWindow(len(points))
+
+	buckets := make([]jacobianPoint, 1<<c)
+	for offset := ((256+c-1)/c - 1) * c; offset >= 0; offset -= c {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		for i := 0; i < c; i++ {
+			result.double(&result)
+		}
+
+		for i := range buckets {
+			buckets[i] = jacobianPoint{}
+		}
+		for i := range points {
+			if d := window(&digits[i], offset, c); d != 0 {
+				buckets[d].addMixed(&buckets[d], &points[i])
+			}
+		}
+
+		// sum of b * buckets[b], done as a running sum from the top.
+		var running, sum jacobianPoint
+		for b := len(buckets) - 1; b > 0; b-- {
+			running.add(&running, &buckets[b])
+			sum.add(&sum, &running)
+		}
+		result.add(&result, &sum)
+	}
+	return result, nil
+}
+
+// straussPoints is the number of points from which the bucket method starts
+// being cheaper than straussMult.
+const straussPoints = 4
+
+// straussWindow is the window straussMult uses, in bits.
+const straussWindow = 4
+
+// straussMult is Strauss' method, for when there are only a few points: it
+// computes the first 2^straussWindow - 1 multiples of every point, then only
+// takes one addition per point per window, plus the doublings shared by all of
+// them.
+func straussMult(ctx context.Context, points []affinePoint, digits [][4]uint64) (jacobianPoint, error) {
+	const entries = 1<<straussWindow - 1
+	var multiplesBuf [(straussPoints - 1) * entries]jacobianPoint
+	var tableBuf [(straussPoints - 1) * entries]affinePoint
+	var productsBuf [(straussPoints - 1) * entries]fieldVal
+	multiples := multiplesBuf[:0]
+	for i := range points {
+		var p jacobianPoint
+		p.setAffine(&points[i])
+		for j := 0; j < entries; j++ {
+			multiples = append(multiples, p)
+			p.addMixed(&p, &points[i])
+		}
+	}
+	table := tableBuf[:len(multiples)]
+	toAffineBatchInto(table, productsBuf[:len(multiples)], multiples)
+
+	var result jacobianPoint
+	for offset := ((256+straussWindow-1)/straussWindow - 1) * straussWindow; offset >= 0; offset -= straussWindow {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		for i := 0; i < straussWindow; i++ {
+			result.double(&result)
+		}
+		for i := range points {
+			if d := window(&digits[i], offset, straussWindow); d != 0 {
+				result.addMixed(&result, &table[i*entries+d-1])
+			}
+		}
+	}
+	return result, nil
+}
+
+func msmWindow(n int) int {
+	switch {
+	case n < 8:
+		return 3
+	case n < 32:
+		return 5
+	case n < 128:
+		return 6
+	case n < 512:
+		return 7
+	case n < 2048:
+		return 9
+	default:
+		return 11
+	}
+}
+
+// scalarLimbs reduces s modulo N and splits it into little-endian 64-bit limbs.
+func scalarLimbs(s *big.Int) (l [4]uint64) {
+	var b [32]byte
+	intToByteInto(b[:], new(big.Int).Mod(s, Curve.N))
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			l[i] = l[i]<<8 | uint64(b[24-i*8+j])
+		}
+	}
+	return l
+}
+
+// window returns the c bits of the scalar starting at bit offset.
+func window(l *[4]uint64, offset, c int) int {
+	limb, shift := offset/64, uint(offset%64)
+	w := l[limb] >> shift
+	if shift+uint(c) > 64 && limb < 3 {
+		w |= l[limb+1] << (64 - shift)
+	}
+	return int(w & (1<<uint(c) - 1))
+}
+
+// baseTable holds j * 2^(baseWindow*i) * G for every window i of baseWindow
+// bits and every value j, so multiplying G by a scalar is just one addition
+// per window. It is only built the first time it is needed. baseMult reads
+// the whole table for every window, so making the windows bigger only pays
+// off up to a point.
+//
+// The table takes baseTableBytes of memory, two 32 byte coordinates per entry,
+// which is 52 windows of 31 entries, about 100KB, with 5 bit windows. Every
+// extra bit of window roughly doubles that.
+const (
+	baseWindow     = 5
+	baseWindows    = (256 + baseWindow - 1) / baseWindow
+	baseEntries    = 1<<baseWindow - 1
+	baseTableBytes = baseWindows * baseEntries * 2 * 32
+)
+
+var (
+	baseTable     [baseWindows][baseEntries]affinePoint
+	baseOffset    affinePoint
+	baseTableOnce sync.Once
+)
+
+func buildBaseTable() {
+	points := make([]jacobianPoint, 0, baseWindows*baseEntries)
+	var g, p jacobianPoint
+	G := newAffinePoint(Curve.Gx, Curve.Gy)
+	g.setAffine(&G)
+
+	for i := 0; i < baseWindows; i++ {
+		p = g
+		for j := 0; j < baseEntries; j++ {
+			points = append(points, p)
+			p.add(&p, &g)
+		}
+		// p is 2^baseWindow * g now.
+		g = p
+	}
+
+	// nobody knows how the discrete logarithm of baseOffset relates to the
+	// table entries, so no partial sum in baseMult will ever be one of them.
+	c := hashToScalar(taggedHash("schnorr/baseMult offset"))
+	points = append(points, multiScalarMult([]affinePoint{G}, []*big.Int{c}))
+
+	affine := toAffineBatch(points)
+	for i := range baseTable {
+		copy(baseTable[i][:], affine[i*baseEntries:(i+1)*baseEntries])
+	}
+	baseOffset = affine[len(affine)-1]
+}
+
+// toAffineBatch converts many points at once with a single inversion, using
+// Montgomery's trick. None of them can be the point at infinity.
+func toAffineBatch(points []jacobianPoint) []affinePoint {
+	affine := make([]affinePoint, len(points))
+	toAffineBatchInto(affine, make([]fieldVal, len(points)), points)
+	return affine
+}
+
+// toAffineBatchInto is toAffineBatch writing the result to affine, with
+// products as scratch space. Both must be as long as points.
+func toAffineBatchInto(affine []affinePoint, products []fieldVal, points []jacobianPoint) {
+	// products[i] = z0 * z1 * ... * zi
+	acc := fieldOne
+	for i := range points {
+		acc.mul(&acc, &points[i].z)
+		products[i] = acc
+	}
+
+	var inv, zInv, zInv2 fieldVal
+	inv.inverse(&acc)
+	for i := len(points) - 1; i >= 0; i-- {
+		if i > 0 {
+			zInv.mul(&inv, &products[i-1])
+			inv.mul(&inv, &points[i].z)
+		} else {
+			zInv = inv
+		}
+		zInv2.square(&zInv)
+		affine[i].x.mul(&points[i].x, &zInv2)
+		zInv2.mul(&zInv2, &zInv)
+		affine[i].y.mul(&points[i].y, &zInv2)
+	}
+}
+
+// baseMult returns k * G. It is faster than Curve.ScalarBaseMult because of
+// baseTable. k must not be a multiple of n.
+//
+// k is usually secret, so baseMult takes the same time and touches the same
+// memory whatever k is: every entry of the table is read for every window,
+// and the addition is done even when the window is zero. It starts from
+// baseOffset instead of the point at infinity and takes it off at the end, so
+// the special cases of addMixed never come up in practice. Converting k from a
+// big.Int is not constant time though.
+func baseMult(k *big.Int) (x, y *big.Int) {
+	baseTableOnce.Do(buildBaseTable)
+
+	l := scalarLimbs(k)
+	offset := baseOffset
+	var p, sum jacobianPoint
+	var entry affinePoint
+	p.setAffine(&offset)
+	for i := 0; i < baseWindows; i++ {
+		j := uint64(window(&l, i*baseWindow, baseWindow))
+		nonzero := 1 ^ ctEqual(j, 0)
+		// j - 1, or any entry at all for j = 0, as the sum is thrown away.
+		want := j - nonzero
+		for c := range baseTable[i] {
+			entry.cmov(&baseTable[i][c], ctEqual(uint64(c), want))
+		}
+		sum.addMixed(&p, &entry)
+		p.cmov(&sum, nonzero)
+	}
+
+	offset.y.neg(&offset.y)
+	p.addMixed(&p, &offset)
+	return p.affine()
+}
+
+// scalarWindow is the number of bits of k that scalarMult handles at a time.
+const scalarWindow = 4
+
+// scalarMult returns k * P, taking the same time and touching the same memory
+// whatever k is, like baseMult. It is for multiplying other points than G by
+// secrets, such as the public key of someone else in ECDH. That point may have
+// been picked to make the special cases of addMixed come up for some k, so
+// every addition is done with addMixedConst. Converting k from a big.Int and
+// building the table of multiples of P, which only depends on P, are not
+// constant time. Returns nil, nil if k is a multiple of n.
+func scalarMult(P *affinePoint, k *big.Int) (x, y *big.Int) {
+	// table[j] = (j+1) * P, none of them infinity as P has prime order.
+	var multiples [1<<scalarWindow - 1]jacobianPoint
+	var table [1<<scalarWindow - 1]affinePoint
+	var products [1<<scalarWindow - 1]fieldVal
+	multiples[0].setAffine(P)
+	multiples[1].double(&multiples[0])
+	for j := 2; j < len(multiples); j++ {
+		multiples[j].addMixed(&multiples[j-1], P)
+	}
+	toAffineBatchInto(table[:], products[:], multiples[:])
+
+	l := scalarLimbs(k)
+	var p, sum jacobianPoint
+	var entry affinePoint
+	for i := 256/scalarWindow - 1; i >= 0; i-- {
+		for d := 0; d < scalarWindow; d++ {
+			p.double(&p)
+		}
+		j := uint64(window(&l, i*scalarWindow, scalarWindow))
+		nonzero := 1 ^ ctEqual(j, 0)
+		// j - 1, or any entry at all for j = 0, as the sum is thrown away.
+		want := j - nonzero
+		for c := range table {
+			entry.cmov(&table[c], ctEqual(uint64(c), want))
+		}
+		sum.addMixedConst(&p, &entry)
+		p.cmov(&sum, nonzero)
+	}
+	return p.affine()
+}
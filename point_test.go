@@ -0,0 +1,223 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"unsafe"
+)
+
+func TestPointArithmetic(t *testing.T) {
+	points := make([]affinePoint, 20)
+	scalars := make([]*big.Int, 20)
+	var expectedX, expectedY *big.Int
+
+	for i := range points {
+		if i == straussPoints {
+			// fewer than straussPoints points go through straussMult.
+			result := multiScalarMult(points[:i], scalars[:i])
+			x, y := result.affine()
+			if x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+				t.Fatalf("multiScalarMult of %d points = (%x, %x), want (%x, %x)", i, x, y, expectedX, expectedY)
+			}
+		}
+
+		k, _ := rand.Int(rand.Reader, Curve.N)
+		scalars[i], _ = rand.Int(rand.Reader, Curve.N)
+
+		x, y := Curve.ScalarBaseMult(intToByte(k))
+		points[i] = newAffinePoint(x, y)
+
+		x, y = Curve.ScalarMult(x, y, intToByte(scalars[i]))
+		if expectedX == nil {
+			expectedX, expectedY = x, y
+		} else {
+			expectedX, expectedY = Curve.Add(expectedX, expectedY, x, y)
+		}
+	}
+
+	result := multiScalarMult(points, scalars)
+	x, y := result.affine()
+	if x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+		t.Fatalf("multiScalarMult = (%x, %x), want (%x, %x)", x, y, expectedX, expectedY)
+	}
+
+	// P + (n-1)P must be the point at infinity.
+	points[1] = points[0]
+	result = multiScalarMult(points[:2], []*big.Int{One, new(big.Int).Sub(Curve.N, One)})
+	if !result.isInfinity() {
+		t.Fatalf("P + (n-1)P is not the point at infinity")
+	}
+}
+
+func TestLiftX(t *testing.T) {
+	k, _ := rand.Int(rand.Reader, Curve.N)
+	x, y := Curve.ScalarBaseMult(intToByte(k))
+	if y.Bit(0) == 1 {
+		y.Sub(Curve.P, y)
+	}
+	p, ok := liftX(x)
+	if !ok || p.x.int().Cmp(x) != 0 || p.y.int().Cmp(y) != 0 {
+		t.Fatalf("liftX(%x) = (%x, %x), want (%x, %x)", x, p.x.int(), p.y.int(), x, y)
+	}
+
+	tests := []struct {
+		x  *big.Int
+		y  string
+		ok bool
+	}{
+		// 1 is the smallest x on the curve, 0 and 5 aren't as x^3 + 7 is not a
+		// square.
+		{Zero, "", false},
+		{One, "4218F20AE6C646B363DB68605822FB14264CA8D2587FDD6FBC750D587E76A7EE", true},
+		{big.NewInt(5), "", false},
+		{Curve.Gx, "483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", true},
+		{Curve.P, "", false},
+	}
+	for _, test := range tests {
+		p, ok := liftX(test.x)
+		if ok != test.ok {
+			t.Fatalf("liftX(%x) ok = %v, want %v", test.x, ok, test.ok)
+		}
+		if !ok {
+			continue
+		}
+		want, _ := new(big.Int).SetString(test.y, 16)
+		if p.y.int().Cmp(want) != 0 {
+			t.Fatalf("liftX(%x) y = %x, want %x", test.x, p.y.int(), want)
+		}
+
+		// and y really is a square root of x^3 + 7.
+		ySq := new(big.Int).Exp(test.x, Three, Curve.P)
+		ySq.Add(ySq, Seven)
+		if new(big.Int).Exp(want, Two, Curve.P).Cmp(ySq.Mod(ySq, Curve.P)) != 0 {
+			t.Fatalf("liftX(%x) y² is not x^3 + 7", test.x)
+		}
+	}
+}
+
+func TestBaseMult(t *testing.T) {
+	edges := []*big.Int{
+		One,
+		Two,
+		big.NewInt(31),
+		big.NewInt(32),
+		new(big.Int).Sub(new(big.Int).Lsh(One, 160), One),
+		new(big.Int).Sub(Curve.N, One),
+		new(big.Int).Lsh(One, 255),
+	}
+	for _, k := range edges {
+		x, y := baseMult(k)
+		ex, ey := Curve.ScalarBaseMult(intToByte(k))
+		if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatalf("baseMult(%x) = (%x, %x), want (%x, %x)", k, x, y, ex, ey)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, Curve.N)
+		x, y := baseMult(k)
+		ex, ey := Curve.ScalarBaseMult(intToByte(k))
+		if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatalf("baseMult(%x) = (%x, %x), want (%x, %x)", k, x, y, ex, ey)
+		}
+	}
+}
+
+func TestScalarMult(t *testing.T) {
+	d, _ := rand.Int(rand.Reader, Curve.N)
+	Px, Py := baseMult(d.Add(d, One))
+	P := newAffinePoint(Px, Py)
+
+	edges := []*big.Int{
+		One,
+		Two,
+		big.NewInt(15),
+		big.NewInt(16),
+		big.NewInt(17),
+		new(big.Int).Sub(new(big.Int).Lsh(One, 160), One),
+		new(big.Int).Sub(Curve.N, One),
+		new(big.Int).Lsh(One, 255),
+	}
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, Curve.N)
+		edges = append(edges, k)
+	}
+	for _, k := range edges {
+		x, y := scalarMult(&P, k)
+		ex, ey := Curve.ScalarMult(Px, Py, intToByte(k))
+		if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatalf("scalarMult(%x) = (%x, %x), want (%x, %x)", k, x, y, ex, ey)
+		}
+	}
+	if x, _ := scalarMult(&P, Curve.N); x != nil {
+		t.Fatalf("scalarMult(n) = %x, want the point at infinity", x)
+	}
+}
+
+func TestAddMixedConst(t *testing.T) {
+	Px, Py := baseMult(Seven)
+	P := newAffinePoint(Px, Py)
+	var a, observed, expected jacobianPoint
+	a.setAffine(&P)
+
+	// the special cases addMixed branches on.
+	negP := P
+	negP.y.neg(&negP.y)
+	var infinity jacobianPoint
+	tests := []struct {
+		name string
+		a    *jacobianPoint
+		b    *affinePoint
+	}{
+		{"P + P", &a, &P},
+		{"P + -P", &a, &negP},
+		{"infinity + P", &infinity, &P},
+		{"P + 2P", new(jacobianPoint).double(&a), &P},
+	}
+	for _, test := range tests {
+		observed.addMixedConst(test.a, test.b)
+		expected.addMixed(test.a, test.b)
+		ox, oy := observed.affine()
+		ex, ey := expected.affine()
+		if (ox == nil) != (ex == nil) || ox != nil && (ox.Cmp(ex) != 0 || oy.Cmp(ey) != 0) {
+			t.Fatalf("addMixedConst(%s) = (%x, %x), want (%x, %x)", test.name, ox, oy, ex, ey)
+		}
+	}
+}
+
+func BenchmarkScalarMult(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, Curve.N)
+	Px, Py := baseMult(k)
+	P := newAffinePoint(Px, Py)
+	b.Run("btcec", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Curve.ScalarMult(Px, Py, intToByte(k))
+		}
+	})
+	b.Run("constant time", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scalarMult(&P, k)
+		}
+	})
+}
+
+func TestBaseTableSize(t *testing.T) {
+	if size := unsafe.Sizeof(baseTable); size != baseTableBytes {
+		t.Fatalf("baseTable takes %d bytes, want baseTableBytes = %d", size, baseTableBytes)
+	}
+}
+
+func BenchmarkScalarBaseMult(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, Curve.N)
+	baseMult(k)
+	b.Run("btcec", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Curve.ScalarBaseMult(intToByte(k))
+		}
+	})
+	b.Run("table", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			baseMult(k)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package schnorr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+// SignRFC6979 signs a 32 byte message like Sign, but with a nonce derived as
+// in RFC6979 with HMAC-SHA256, for interoperability with tools that expect
+// RFC6979 nonces. The private key it is derived from is the one Sign actually
+// signs with, that is negated if its public key has an odd y, and the message
+// is used as the hash. Like the nonce from Sign with a nil aux it depends only
+// on the key and the message, but it is drawn from an HMAC-DRBG rather than
+// being a plain hash of them.
+//
+// The signatures are valid BIP340 signatures, but not the ones Sign gives:
+// BIP340 nonces are a tagged hash that mixes in aux and the public key.
+// https://tools.ietf.org/html/rfc6979#section-3.2
+func SignRFC6979(privateKey *big.Int, message [32]byte) ([64]byte, error) {
+	return signWith(privateKey, message, nil, nonceRFC6979, challenge)
+}
+
+func nonceRFC6979(d, Px *big.Int, message [32]byte, aux []byte) (*big.Int, error) {
+	h1 := new(big.Int).SetBytes(message[:])
+	h1.Mod(h1, Curve.N)
+	x := intToByte(d)
+	defer zeroBytes(x)
+
+	V := make([]byte, 32)
+	K := make([]byte, 32)
+	for i := range V {
+		V[i] = 1
+	}
+	mac := func(data ...[]byte) []byte {
+		h := hmac.New(sha256.New, K)
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+
+	K = mac(V, []byte{0}, x, intToByte(h1))
+	V = mac(V)
+	K = mac(V, []byte{1}, x, intToByte(h1))
+	V = mac(V)
+	for {
+		V = mac(V)
+		k := new(big.Int).SetBytes(V)
+		if k.Sign() > 0 && k.Cmp(Curve.N) < 0 {
+			zeroBytes(K)
+			return k, nil
+		}
+		K = mac(V, []byte{0})
+		V = mac(V)
+	}
+}
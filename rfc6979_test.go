@@ -0,0 +1,58 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestNonceRFC6979(t *testing.T) {
+	// a commonly used secp256k1 vector, with the private key 1.
+	message := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	k, _ := nonceRFC6979(One, nil, message, nil)
+	expected, _ := new(big.Int).SetString("8F8A276C19F4149656B280621E358CCE24F5F52542772691EE69063B74F15D15", 16)
+	if k.Cmp(expected) != 0 {
+		t.Fatalf("nonceRFC6979(1, %x) = %x, want %x", message, k, expected)
+	}
+
+	// btcec uses RFC6979 for ECDSA, where r is the x of k*G.
+	for i := 0; i < 8; i++ {
+		d, _, _ := GenerateKeyPair(nil)
+		message[0] = byte(i)
+		k, _ := nonceRFC6979(d, nil, message, nil)
+		privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), intToByte(d))
+		signature, err := privateKey.Sign(message[:])
+		if err != nil {
+			t.Fatalf("Unexpected error from btcec: %v", err)
+		}
+		kGx, _ := Curve.ScalarBaseMult(intToByte(k))
+		if kGx.Mod(kGx, Curve.N).Cmp(signature.R) != 0 {
+			t.Fatalf("nonceRFC6979 gives k*G = %x, btcec gives r = %x", kGx, signature.R)
+		}
+	}
+}
+
+func TestSignRFC6979(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	var message [32]byte
+	copy(message[:], "deterministic the RFC6979 way")
+
+	signature, err := SignRFC6979(d, message)
+	if err != nil {
+		t.Fatalf("Unexpected error from SignRFC6979: %v", err)
+	}
+	if ok, err := Verify(publicKey, message, signature); !ok {
+		t.Fatalf("Verify of a SignRFC6979 signature failed: %v", err)
+	}
+	if again, _ := SignRFC6979(d, message); again != signature {
+		t.Fatalf("SignRFC6979 is not deterministic: %x != %x", again, signature)
+	}
+	if bip340, _ := Sign(d, message, nil); bip340 == signature {
+		t.Fatalf("SignRFC6979 gave the same nonce as Sign")
+	}
+	if _, err := SignRFC6979(new(big.Int), message); err != ErrKeyOutOfRange {
+		t.Fatalf("SignRFC6979 with a zero key = %v, want %v", err, ErrKeyOutOfRange)
+	}
+}
@@ -0,0 +1,69 @@
+package schnorr
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+)
+
+// SafeSigner signs with one private key and remembers the nonce point R of the
+// last signatures it made. If the same R ever comes up again for a different
+// message, which only happens when the nonce generation is broken, it refuses
+// to give out the signature, as the two together would reveal the key.
+//
+// It is safe to use from many goroutines at once.
+type SafeSigner struct {
+	privateKey *big.Int
+	size       int
+
+	mu    sync.Mutex
+	order *list.List // of safeSignerEntry, most recent first
+	seen  map[[32]byte]*list.Element
+
+	// sign is Sign, unless a test needs to break it.
+	sign func(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error)
+}
+
+type safeSignerEntry struct {
+	r, message [32]byte
+}
+
+// NewSafeSigner returns a SafeSigner for the private key that remembers the
+// last size signatures.
+func NewSafeSigner(privateKey *big.Int, size int) *SafeSigner {
+	return &SafeSigner{
+		privateKey: privateKey,
+		size:       size,
+		order:      list.New(),
+		seen:       make(map[[32]byte]*list.Element, size),
+		sign:       Sign,
+	}
+}
+
+// Sign a 32 byte message, see Sign. Returns ErrNonceReuse if a recent
+// signature of a different message had the same R.
+func (s *SafeSigner) Sign(message [32]byte, aux []byte) ([64]byte, error) {
+	signature, err := s.sign(s.privateKey, message, aux)
+	if err != nil {
+		return signature, err
+	}
+	r := [32]byte{}
+	copy(r[:], signature[:32])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.seen[r]; ok {
+		if e.Value.(safeSignerEntry).message != message {
+			return [64]byte{}, ErrNonceReuse
+		}
+		s.order.MoveToFront(e)
+		return signature, nil
+	}
+
+	s.seen[r] = s.order.PushFront(safeSignerEntry{r: r, message: message})
+	if s.order.Len() > s.size {
+		oldest := s.order.Remove(s.order.Back()).(safeSignerEntry)
+		delete(s.seen, oldest.r)
+	}
+	return signature, nil
+}
@@ -0,0 +1,56 @@
+package schnorr
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestSafeSigner(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	publicKey, _ := GetPublicKey(d)
+	s := NewSafeSigner(d, 2)
+
+	var message [32]byte
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(message [32]byte) {
+			defer wg.Done()
+			signature, err := s.Sign(message, make([]byte, 32))
+			if err != nil {
+				t.Errorf("Unexpected error from SafeSigner.Sign: %v", err)
+			} else if ok, err := Verify(publicKey, message, signature); !ok {
+				t.Errorf("Verify of a SafeSigner signature failed: %v", err)
+			}
+		}([32]byte{byte(i)})
+	}
+	wg.Wait()
+
+	// a broken nonce: the same message again is fine, a different one is not.
+	k0 := big.NewInt(42)
+	s.sign = func(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
+		return SignWithNonce(privateKey, message, k0)
+	}
+	if _, err := s.Sign(message, nil); err != nil {
+		t.Fatalf("Unexpected error from SafeSigner.Sign: %v", err)
+	}
+	if _, err := s.Sign(message, nil); err != nil {
+		t.Fatalf("SafeSigner.Sign of the same message again = %v", err)
+	}
+	other := message
+	other[31] = 1
+	if signature, err := s.Sign(other, nil); err != ErrNonceReuse || signature != [64]byte{} {
+		t.Fatalf("SafeSigner.Sign with a reused nonce = %x, %v, want ErrNonceReuse", signature, err)
+	}
+
+	// it only remembers the last 2.
+	for _, k := range []int64{43, 44} {
+		k0 = big.NewInt(k)
+		s.Sign(message, nil)
+	}
+	k0 = big.NewInt(42)
+	if _, err := s.Sign(other, nil); err != nil {
+		t.Fatalf("SafeSigner.Sign after the nonce was forgotten = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Scheme is a Schnorr signature scheme over secp256k1 with the same structure
+// as BIP340 but other hash functions, for experimental protocols. The zero
+// value is BIP340 itself: Scheme{}.Sign and Scheme{}.Verify give the same
+// results as Sign and Verify.
+//
+// Signatures made with a Scheme that changes ChallengeHash only verify with a
+// Scheme that has the same ChallengeHash, and never with Verify.
+type Scheme struct {
+	// ChallengeHash hashes r || P || m, 96 bytes, into the challenge, which is
+	// then reduced modulo n. If nil, it is the BIP340 tagged hash.
+	ChallengeHash func([]byte) [32]byte
+
+	// NonceHash hashes d || P || m || aux, with aux left out if it is nil,
+	// into the nonce, which is then reduced modulo n. d is the private key,
+	// negated if needed so that P has an even y. The result must be secret and
+	// must never repeat for different messages. If nil, the nonce is derived
+	// as in Sign.
+	NonceHash func([]byte) [32]byte
+}
+
+// Sign a 32 byte message with the private key, see Sign.
+func (sc Scheme) Sign(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
+	return signWith(privateKey, message, aux, sc.nonce(), sc.challenge())
+}
+
+// Verify a 64 byte signature of a 32 byte message against the public key, see
+// Verify.
+func (sc Scheme) Verify(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, error) {
+	return verifyWith(publicKey, message, signature, sc.challenge())
+}
+
+func (sc Scheme) nonce() nonceFunc {
+	if sc.NonceHash == nil {
+		return getK0
+	}
+	return func(d, Px *big.Int, message [32]byte, aux []byte) (*big.Int, error) {
+		if aux != nil && len(aux) != 32 {
+			return nil, fmt.Errorf("%w, not %d", ErrBadAuxLength, len(aux))
+		}
+		data := make([]byte, 96, 96+len(aux))
+		intToByteInto(data[:32], d)
+		intToByteInto(data[32:64], Px)
+		copy(data[64:], message[:])
+		data = append(data, aux...)
+		h := sc.NonceHash(data)
+		zeroBytes(data)
+
+		k0 := hashToScalar(h)
+		zeroBytes(h[:])
+		if k0.Sign() == 0 {
+			return nil, ErrZeroNonce
+		}
+		return k0, nil
+	}
+}
+
+func (sc Scheme) challenge() challengeFunc {
+	if sc.ChallengeHash == nil {
+		return challenge
+	}
+	return func(rX, publicKey, message []byte) *big.Int {
+		data := make([]byte, 0, 96)
+		data = append(data, rX...)
+		data = append(data, publicKey...)
+		data = append(data, message...)
+		return hashToScalar(sc.ChallengeHash(data))
+	}
+}
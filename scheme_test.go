@@ -0,0 +1,59 @@
+package schnorr
+
+import (
+	"crypto/sha512"
+	"testing"
+)
+
+func TestSchemeDefault(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	publicKey, _ := GetPublicKey(d)
+
+	for _, aux := range [][]byte{nil, make([]byte, 32)} {
+		expected, _ := Sign(d, message, aux)
+		signature, err := Scheme{}.Sign(d, message, aux)
+		if err != nil {
+			t.Fatalf("Unexpected error from Scheme.Sign: %v", err)
+		}
+		if signature != expected {
+			t.Fatalf("Scheme{}.Sign = %x, want %x", signature, expected)
+		}
+		if ok, err := (Scheme{}).Verify(publicKey, message, signature); !ok {
+			t.Fatalf("Scheme{}.Verify failed: %v", err)
+		}
+	}
+}
+
+func TestSchemeCustomHash(t *testing.T) {
+	sha512Truncated := func(data []byte) [32]byte {
+		h := sha512.Sum512(data)
+		var out [32]byte
+		copy(out[:], h[:32])
+		return out
+	}
+	scheme := Scheme{ChallengeHash: sha512Truncated, NonceHash: sha512Truncated}
+
+	d, publicKey, _ := GenerateKeyPair(nil)
+	var message [32]byte
+	copy(message[:], "a different challenge")
+	for _, aux := range [][]byte{nil, make([]byte, 32)} {
+		signature, err := scheme.Sign(d, message, aux)
+		if err != nil {
+			t.Fatalf("Unexpected error from Scheme.Sign: %v", err)
+		}
+		if ok, err := scheme.Verify(publicKey, message, signature); !ok {
+			t.Fatalf("Scheme.Verify failed: %v", err)
+		}
+		if ok, _ := Verify(publicKey, message, signature); ok {
+			t.Fatalf("Verify accepted a signature with a different challenge hash")
+		}
+		if ok, _ := (Scheme{NonceHash: sha512Truncated}).Verify(publicKey, message, signature); ok {
+			t.Fatalf("Scheme.Verify with the default challenge accepted a signature with a different one")
+		}
+	}
+
+	if _, err := scheme.Sign(d, message, make([]byte, 31)); err == nil {
+		t.Fatalf("Scheme.Sign with a 31 byte aux should have failed")
+	}
+}
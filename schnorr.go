@@ -1,13 +1,15 @@
 package schnorr
 
 import (
-	"bytes"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"sync"
 
 	"github.com/btcsuite/btcd/btcec"
 )
@@ -28,21 +30,65 @@ var (
 	Four = new(big.Int).SetInt64(4)
 	// Seven holds a big integer of 7
 	Seven = new(big.Int).SetInt64(7)
+	// N1 holds a big integer of N-1
+	N1 = new(big.Int).Sub(Curve.N, One)
 	// N2 holds a big integer of N-2
 	N2 = new(big.Int).Sub(Curve.N, Two)
 )
 
 // Sign a 32 byte message with the private key, returning a 64 byte signature.
-// Calling with a nil aux will cause the function to use a deterministic nonce.
+// Calling with a nil aux will cause the function to use a deterministic nonce,
+// which is pinned by SignDeterministic.
+// Either way the nonce only depends on the arguments, so in the practically
+// impossible case that it is zero there is nothing to do but return
+// ErrZeroNonce, see SignRandomized.
+//
+// The multiplications by G run in constant time, the arithmetic on the scalars
+// is done with math/big and doesn't.
 // https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki#signing
 func Sign(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
+	return signWith(privateKey, message, aux, getK0, challenge)
+}
+
+// SignDeterministic signs a 32 byte message like Sign with a nil aux, the nonce
+// being derived from nothing but the private key and the message. Its output
+// is guaranteed to stay the same from one version to the next, so signatures
+// made by it can be stored and made again later to check them. Should the
+// nonce derivation ever need to change, that will be done in a new function
+// and not here.
+//
+// That nonce is not the one BIP340 derives, so these signatures are not the
+// ones other BIP340 implementations make with a zero aux, although any BIP340
+// verifier accepts them.
+func SignDeterministic(privateKey *big.Int, message [32]byte) ([64]byte, error) {
+	return Sign(privateKey, message, nil)
+}
+
+// SignBytes is Sign with the private key given as 32 big-endian bytes, the
+// way it usually comes out of storage or a KDF. Other lengths return
+// ErrBadScalarLength, and keys outside 1..n-1 ErrKeyOutOfRange.
+func SignBytes(privateKey []byte, message [32]byte, aux []byte) ([64]byte, error) {
+	d, err := ParseScalar(privateKey)
+	if err == ErrScalarOutOfRange {
+		return [64]byte{}, ErrKeyOutOfRange
+	}
+	if err != nil {
+		return [64]byte{}, err
+	}
+	defer zeroInt(d)
+	return Sign(d, message, aux)
+}
+
+// signWith is Sign with the nonce derivation and the challenge as parameters,
+// so Scheme can swap them.
+func signWith(privateKey *big.Int, message [32]byte, aux []byte, nonce nonceFunc, challenge challengeFunc) ([64]byte, error) {
 	sig := [64]byte{}
 	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
-		return sig, errors.New("the private key must be an integer in the range 1..n-1")
+		return sig, ErrKeyOutOfRange
 	}
 
 	// d0 = privateKey
-	Px, Py := Curve.ScalarBaseMult(intToByte(privateKey))
+	Px, Py := baseMult(privateKey)
 	d := new(big.Int)
 
 	if new(big.Int).And(Py, One).Cmp(Zero) == 0 {
@@ -51,120 +97,470 @@ func Sign(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
 	} else {
 		d = d.Sub(Curve.N, privateKey)
 	}
+	defer zeroInt(d)
+
+	k0, err := nonce(d, Px, message, aux)
+	if err != nil {
+		return sig, err
+	}
+	defer zeroInt(k0)
+
+	return sign(d, Px, Py, k0, message, challenge), nil
+}
+
+// SignBatch signs a list of 32 byte messages with the private key, with the
+// deterministic nonce Sign uses for a nil aux. It gives the same signatures
+// as calling Sign for each message, but checks the key and computes the public
+// key only once.
+func SignBatch(privateKey *big.Int, messages [][32]byte) ([][64]byte, error) {
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return nil, ErrKeyOutOfRange
+	}
+
+	Px, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	defer zeroInt(d)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+
+	signatures := make([][64]byte, len(messages))
+	for i := range messages {
+		k0, err := getK0(d, Px, messages[i], nil)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		signatures[i] = sign(d, Px, Py, k0, messages[i], challenge)
+		zeroInt(k0)
+	}
+	return signatures, nil
+}
 
+// getK0 derives the nonce for Sign from the already negated private key d, as
+// in BIP340 if aux is given and deterministically from d and the message if
+// not.
+func getK0(d, Px *big.Int, message [32]byte, aux []byte) (*big.Int, error) {
 	var k0 *big.Int
 	if aux != nil {
 		if len(aux) != 32 {
-			return sig, fmt.Errorf("aux must be 32 bytes, not %d", len(aux))
+			return nil, fmt.Errorf("%w, not %d", ErrBadAuxLength, len(aux))
 		}
 
-		t := new(big.Int).Xor(
-			d,
-			new(big.Int).SetBytes(taggedHash("BIP0340/aux", aux)),
-		)
+		auxHash := taggedHash("BIP0340/aux", aux)
+		t := new(big.Int).Xor(d, new(big.Int).SetBytes(auxHash[:]))
+		tBytes := intToByte(t)
+		zeroInt(t)
 
-		bundle := bytes.Buffer{}
-		bundle.Write(t.Bytes())
-		bundle.Write(Px.Bytes())
-		bundle.Write(message[:])
-
-		k0 = new(big.Int).Mod(
-			new(big.Int).SetBytes(taggedHash("BIP0340/nonce", bundle.Bytes())),
-			Curve.N,
-		)
+		nonceHash := taggedHash("BIP0340/nonce", tBytes, intToByte(Px), message[:])
+		zeroBytes(tBytes)
+		k0 = hashToScalar(nonceHash)
+		zeroBytes(nonceHash[:])
 	} else {
-		k0 = deterministicGetK0(d.Bytes(), message)
+		dBytes := d.Bytes()
+		k0 = deterministicGetK0(dBytes, message)
+		zeroBytes(dBytes)
 	}
 	if k0.Sign() == 0 {
-		return sig, errors.New("k0 is zero")
+		return nil, ErrZeroNonce
+	}
+	return k0, nil
+}
+
+// SignRandomized signs a 32 byte message like Sign, with 32 bytes read from
+// random as aux. Calling with a nil random will cause the function to use
+// crypto/rand.
+//
+// Unlike Sign, which can only give up with ErrZeroNonce if the nonce comes out
+// as zero, SignRandomized tries again with a fresh aux, so it only ever
+// returns ErrZeroNonce if random keeps giving the same bytes.
+func SignRandomized(random io.Reader, privateKey *big.Int, message [32]byte) ([64]byte, error) {
+	return signRandomized(random, privateKey, message, getK0)
+}
+
+// maxNonceAttempts is how many times signRandomized reads a new aux after a
+// zero nonce, which for a working random source is already absurdly many.
+const maxNonceAttempts = 4
+
+func signRandomized(random io.Reader, privateKey *big.Int, message [32]byte, nonce nonceFunc) ([64]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	aux := make([]byte, 32)
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(random, aux); err != nil {
+			return [64]byte{}, err
+		}
+		sig, err := signWith(privateKey, message, aux, nonce, challenge)
+		if err != ErrZeroNonce || i == maxNonceAttempts-1 {
+			return sig, err
+		}
+	}
+}
+
+// SignWithCounter signs a 32 byte message like Sign, with the counter as aux,
+// big-endian in the last 8 of its 32 bytes, so that signing the same message
+// again with another counter gives another nonce and another signature. It is
+// meant for stateful signers that won't sign twice with the same nonce even
+// for identical messages; Sign with a nil aux already never reuses a nonce
+// across different messages.
+//
+// The caller owns the counter: it has to be persisted and only ever go up, or
+// the guarantee is lost.
+func SignWithCounter(privateKey *big.Int, message [32]byte, counter uint64) ([64]byte, error) {
+	aux := make([]byte, 32)
+	binary.BigEndian.PutUint64(aux[24:], counter)
+	return Sign(privateKey, message, aux)
+}
+
+// SignWithNonce signs a 32 byte message like Sign, but with a nonce k0 chosen
+// by the caller instead of derived from the key and message, for protocols
+// that need to agree on the nonce beforehand. k0 must be in the range 1..n-1.
+//
+// WARNING: k0 must be secret, uniformly random and never, ever used again.
+// Anyone who sees two signatures made with the same k0 over different messages
+// can compute the private key from them.
+func SignWithNonce(privateKey *big.Int, message [32]byte, k0 *big.Int) ([64]byte, error) {
+	sig := [64]byte{}
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return sig, ErrKeyOutOfRange
+	}
+	if k0.Cmp(One) < 0 || k0.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return sig, ErrNonceOutOfRange
+	}
+
+	Px, Py := baseMult(privateKey)
+	d := new(big.Int).Set(privateKey)
+	defer zeroInt(d)
+	if Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+
+	return sign(d, Px, Py, k0, message, challenge), nil
+}
+
+// SignAndVerify signs like Sign and then verifies the signature against the
+// public key of privateKey, returning an error instead of a signature that
+// doesn't verify. A bug or a hardware fault while signing can give a bad
+// signature, and with some faults one that leaks the key if it is published,
+// so this is a defensive check for places where that matters. It costs a
+// Verify and a multiplication by G on top of Sign, which is why Sign doesn't
+// do it by default.
+func SignAndVerify(privateKey *big.Int, message [32]byte, aux []byte) ([64]byte, error) {
+	sig, err := Sign(privateKey, message, aux)
+	if err != nil {
+		return sig, err
+	}
+	publicKey, err := GetPublicKey(privateKey)
+	if err != nil {
+		return [64]byte{}, err
 	}
+	if ok, err := Verify(publicKey, message, sig); !ok {
+		return [64]byte{}, fmt.Errorf("the signature doesn't verify against the signing key: %w", err)
+	}
+	return sig, nil
+}
 
-	Rx, Ry := Curve.ScalarBaseMult(intToByte(k0))
+// sign computes the signature given the already negated private key d, its
+// public key point and the nonce.
+func sign(d, Px, Py, k0 *big.Int, message [32]byte, challenge challengeFunc) [64]byte {
+	sig := [64]byte{}
+	Rx, Ry := baseMult(k0)
 	k := getK(Ry, k0)
 
 	rX := intToByte(Rx)
-	e := getE(Px, Py, rX, message)
+	e := challenge(rX, intToByte(Px), message[:])
 	e.Mul(e, d)
-	k.Add(k, e)
-	k.Mod(k, Curve.N)
+	s := new(big.Int).Add(k, e)
+	s.Mod(s, Curve.N)
+	zeroInt(k)
+	zeroInt(e)
 
 	copy(sig[:32], rX)
-	copy(sig[32:], intToByte(k))
-	return sig, nil
+	intToByteInto(sig[32:], s)
+	return sig
 }
 
 // Verify a 64 byte signature of a 32 byte message against the public key.
 // Returns an error if verification fails.
 // https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki#verification
 func Verify(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, error) {
-	Px, Py := Unmarshal(Curve, publicKey[:])
+	return verifyWith(publicKey, message, signature, challenge)
+}
+
+// verifyWith is Verify with the challenge as a parameter, so Scheme can swap
+// it.
+func verifyWith(publicKey [32]byte, message [32]byte, signature [64]byte, challenge challengeFunc) (bool, error) {
+	P, ok := liftPublicKey(publicKey)
+	if !ok {
+		// only done on failure, to say what is wrong with the key.
+		return false, ValidatePublicKey(publicKey[:])
+	}
+	return verify(&P, message, signature, challenge)
+}
+
+// VerifyWithR verifies a signature like Verify, and if it is valid also
+// returns the nonce point R in 33 byte compressed form, or nil if it is not.
+// Verify only accepts an R with an even y and an x equal to the first half of
+// the signature, so it always starts with 0x02, followed by that half.
+func VerifyWithR(publicKey [32]byte, message [32]byte, signature [64]byte) (bool, []byte, error) {
+	if ok, err := Verify(publicKey, message, signature); !ok {
+		return false, nil, err
+	}
+	R := make([]byte, 33)
+	R[0] = 2
+	copy(R[1:], signature[:32])
+	return true, R, nil
+}
+
+// VerifyWithChallenge checks that sG = R + eP, where R is the point with x
+// coordinate r and an even y, for protocols where e was already computed by
+// somebody else. It is what Verify does after computing e, so it only means
+// anything if the caller made sure that e is the right challenge, for example
+// with Challenge.
+func VerifyWithChallenge(publicKey [32]byte, r [32]byte, s *big.Int, e *big.Int) (bool, error) {
+	P, ok := liftPublicKey(publicKey)
+	if !ok {
+		return false, ErrVerifyFailed
+	}
+	rInt := new(big.Int).SetBytes(r[:])
+	if rInt.Cmp(Curve.P) >= 0 {
+		return false, ErrRTooLarge
+	}
+	if s.Cmp(Curve.N) >= 0 {
+		return false, ErrSTooLarge
+	}
+	if s.Sign() <= 0 || e.Sign() < 0 || e.Cmp(Curve.N) >= 0 {
+		return false, ErrVerifyFailed
+	}
+	return verifyChallenge(&P, rInt, s, e)
+}
+
+// liftPublicKey decodes a 32 byte public key like Unmarshal, but in the field
+// code instead of math/big.
+func liftPublicKey(publicKey [32]byte) (P affinePoint, ok bool) {
+	P, ok = liftXBytes(publicKey[:])
+	if !ok {
+		return P, false
+	}
+
+	// liftX only returns points on the curve, but this is cheap and keeps
+	// Verify safe from changes there.
+	if !P.isOnCurve() {
+		return P, false
+	}
+	return P, true
+}
 
-	if Px == nil || Py == nil || !Curve.IsOnCurve(Px, Py) {
-		return false, errors.New("signature verification failed")
+// VerifyBool is Verify for callers that only care whether the signature is
+// valid. The errors Verify returns are all preallocated, so the failure path
+// costs no more than the success path either way.
+func VerifyBool(publicKey [32]byte, message [32]byte, signature [64]byte) bool {
+	ok, _ := Verify(publicKey, message, signature)
+	return ok
+}
+
+// VerifyAny verifies the signature against each of the public keys in turn,
+// for key rotation, returning the index of the first one it is valid for. If
+// there is none the index is -1 and the error is ErrVerifyFailed, or whatever
+// Verify said was wrong with the signature itself.
+func VerifyAny(publicKeys [][32]byte, message [32]byte, signature [64]byte) (int, error) {
+	for i, publicKey := range publicKeys {
+		ok, err := Verify(publicKey, message, signature)
+		if ok {
+			return i, nil
+		}
+		// r or s out of range, no key is going to do.
+		if err == ErrRTooLarge || err == ErrSTooLarge {
+			return -1, err
+		}
 	}
-	r := new(big.Int).SetBytes(signature[:32])
+	return -1, ErrVerifyFailed
+}
+
+// verifyScratch holds big.Ints that verify and verifyChallenge only need while
+// they run, so that they can be reused from verifyScratchPool instead of
+// allocated for every signature. Each call gets its own, so concurrent calls
+// never share them.
+type verifyScratch struct {
+	r, s, negE big.Int
+
+	// challenge is r || P || m, which would otherwise be copied to the heap
+	// for every call as it goes through a challengeFunc.
+	challenge [96]byte
+}
+
+var verifyScratchPool = sync.Pool{
+	New: func() interface{} { return new(verifyScratch) },
+}
+
+func verify(P *affinePoint, message [32]byte, signature [64]byte, challenge challengeFunc) (bool, error) {
+	scratch := verifyScratchPool.Get().(*verifyScratch)
+	defer verifyScratchPool.Put(scratch)
+
+	r := scratch.r.SetBytes(signature[:32])
 	if r.Cmp(Curve.P) >= 0 {
-		return false, errors.New("r is larger than or equal to field size")
+		return false, ErrRTooLarge
 	}
-	s := new(big.Int).SetBytes(signature[32:])
+	s := scratch.s.SetBytes(signature[32:])
 	if s.Cmp(Curve.N) >= 0 {
-		return false, errors.New("s is larger than or equal to curve order")
+		return false, ErrSTooLarge
 	}
+	if s.Sign() == 0 {
+		// BIP340 doesn't rule it out, but an honest signer gets s = 0 with
+		// negligible probability, so it can only be a degenerate signature.
+		return false, ErrVerifyFailed
+	}
+
+	c := scratch.challenge[:]
+	copy(c[:32], signature[:32])
+	P.x.putBytes(c[32:64])
+	copy(c[64:], message[:])
+	e := challenge(c[:32], c[32:64], c[64:])
+	return verifyChallenge(P, r, s, e)
+}
 
-	e := getE(Px, Py, intToByte(r), message)
-	sGx, sGy := Curve.ScalarBaseMult(intToByte(s))
-	// e.Sub(Curve.N, e)
-	ePx, ePy := Curve.ScalarMult(Px, Py, intToByte(e))
-	ePy.Sub(Curve.P, ePy)
-	Rx, Ry := Curve.Add(sGx, sGy, ePx, ePy)
+// verifyChallenge checks that R = sG - eP has an even y and x = r.
+func verifyChallenge(P *affinePoint, r, s, e *big.Int) (bool, error) {
+	scratch := verifyScratchPool.Get().(*verifyScratch)
+	defer verifyScratchPool.Put(scratch)
 
-	if (Rx.Sign() == 0 && Ry.Sign() == 0) ||
-		new(big.Int).And(Ry, One).Cmp(One) == 0 /* Ry is not even */ ||
-		Rx.Cmp(r) != 0 {
-		return false, errors.New("signature verification failed")
+	// both multiplications at once. Nothing here is secret, so there is no
+	// need for the constant time baseMult.
+	R := multiScalarMult(
+		[]affinePoint{generator, *P},
+		[]*big.Int{s, scratch.negE.Sub(Curve.N, e)},
+	)
+	if R.isInfinity() {
+		return false, ErrVerifyFailed
+	}
+	var rField fieldVal
+	rField.setInt(r)
+	if A := R.toAffine(); A.y.isOdd() || !A.x.equals(&rField) {
+		return false, ErrVerifyFailed
 	}
 	return true, nil
 }
 
+// Challenge returns the BIP340 challenge e = hash(r || P || m) mod n, for
+// protocols built on top of Schnorr signatures that need it directly. rX is
+// the x coordinate of the nonce point, publicKey is a 32 byte public key and
+// message is 32 bytes.
+func Challenge(rX []byte, publicKey []byte, message []byte) (*big.Int, error) {
+	if len(rX) != 32 {
+		return nil, errors.New("rX must be 32 bytes")
+	}
+	if new(big.Int).SetBytes(rX).Cmp(Curve.P) >= 0 {
+		return nil, ErrRTooLarge
+	}
+	if len(publicKey) != 32 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadPublicKeyLength, len(publicKey))
+	}
+	if x, _ := Unmarshal(Curve, publicKey); x == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if len(message) != 32 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadMessageLength, len(message))
+	}
+	return challenge(rX, publicKey, message), nil
+}
+
+// NegateChallenge returns n - e, or 0 for e = 0. Verify computes
+// R = sG - eP as sG + (n - e)P, negating the scalar rather than the point so
+// both multiplications can be done at once; it is the same as negating the y
+// of eP, for backends where that is the cheaper one.
+func NegateChallenge(e *big.Int) *big.Int {
+	negE := new(big.Int).Sub(Curve.N, e)
+	return negE.Mod(negE, Curve.N)
+}
+
 func getE(Px, Py *big.Int, rX []byte, m [32]byte) *big.Int {
-	bundle := bytes.Buffer{}
-	bundle.Write(rX)
-	bundle.Write(Px.Bytes())
-	bundle.Write(m[:])
-	return new(big.Int).Mod(
-		new(big.Int).SetBytes(taggedHash("BIP0340/challenge", bundle.Bytes())),
-		Curve.N,
-	)
+	return challenge(rX, intToByte(Px), m[:])
+}
+
+// nonceFunc derives the nonce k0 for the negated private key d, as getK0 does.
+type nonceFunc func(d, Px *big.Int, message [32]byte, aux []byte) (*big.Int, error)
+
+// challengeFunc computes the challenge from the encoded r, public key and
+// message, as challenge does.
+type challengeFunc func(rX, publicKey, message []byte) *big.Int
+
+// challenge is what Challenge and getE share, without any checks, as getE is
+// only called with points that are known to be valid.
+func challenge(rX, publicKey, message []byte) *big.Int {
+	return hashToScalar(taggedHash("BIP0340/challenge", rX, publicKey, message))
+}
+
+// hashToScalar reduces a 32 byte hash modulo n, as BIP340 does for both the
+// challenge and the nonce. This is biased towards the values below 2^256 - n,
+// but n is so close to 2^256 that a hash is at least n with a probability
+// below 2^-127, so nobody will ever see the bias, and reducing a wider hash
+// would make the signatures incompatible with BIP340.
+func hashToScalar(h [32]byte) *big.Int {
+	i := new(big.Int).SetBytes(h[:])
+	return i.Mod(i, Curve.N)
 }
 
+// getK returns k0, or n - k0 if Ry is odd, always as a new big.Int.
 func getK(Ry, k0 *big.Int) *big.Int {
 	if new(big.Int).And(Ry, One).Cmp(Zero) == 0 {
 		// is even
-		return k0
+		return new(big.Int).Set(k0)
 	} else {
 		return new(big.Int).Sub(Curve.N, k0)
 	}
 }
 
 func deterministicGetK0(d []byte, message [32]byte) *big.Int {
-	h := sha256.Sum256(append(d, message[:]...))
-	i := new(big.Int).SetBytes(h[:])
-	return i.Mod(i, Curve.N)
+	data := append(append(make([]byte, 0, len(d)+32), d...), message[:]...)
+	h := sha256.Sum256(data)
+	zeroBytes(data)
+	k0 := hashToScalar(h)
+	zeroBytes(h[:])
+	return k0
 }
 
+// deterministicGetRandA returns a random coefficient in the range 1..n-1 for
+// batch verification. Reducing 32 random bytes is slightly biased, but like in
+// hashToScalar nobody can tell, and it saves rand.Int's allocations, which add
+// up over a large batch.
 func deterministicGetRandA() (*big.Int, error) {
-	a, err := rand.Int(rand.Reader, N2)
-	if err != nil {
+	var b [32]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
 		return nil, err
 	}
 
+	a := new(big.Int).SetBytes(b[:])
+	a.Mod(a, N1)
 	return a.Add(a, One), nil
 }
 
 func intToByte(i *big.Int) []byte {
-	b1, b2 := [32]byte{}, i.Bytes()
-	copy(b1[32-len(b2):], b2)
-	return b1[:]
+	b := make([]byte, 32)
+	intToByteInto(b, i)
+	return b
+}
+
+// intToByteInto writes i as 32 bytes to the start of dst, so hot paths can use
+// a buffer they already have instead of allocating one every time.
+func intToByteInto(dst []byte, i *big.Int) {
+	i.FillBytes(dst[:32])
+}
+
+// zeroInt overwrites the words backing i before setting it to zero. It is only
+// a best effort: big.Int may have left copies behind while growing or during
+// arithmetic, and the garbage collector may move things around.
+func zeroInt(i *big.Int) {
+	words := i.Bits()
+	for j := range words {
+		words[j] = 0
+	}
+	i.SetInt64(0)
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
 }
 
 // Marshal just encodes x as bytes. Unnecessary.
@@ -172,53 +568,103 @@ func Marshal(curve elliptic.Curve, x, y *big.Int) []byte {
 	return x.Bytes()
 }
 
-// Unmarshal converts a point, serialised by Marshal, into an x, y pair. On
-// error, x = nil.
-func Unmarshal(curve elliptic.Curve, data []byte) (x, y *big.Int) {
+// MarshalUncompressed encodes a point in the 65 byte uncompressed SEC1 form,
+// 4 followed by x and y.
+func MarshalUncompressed(curve elliptic.Curve, x, y *big.Int) []byte {
 	byteLen := (curve.Params().BitSize + 7) >> 3
-	if len(data) != byteLen {
-		return
-	}
+	out := make([]byte, 1+2*byteLen)
+	out[0] = 4
+	x.FillBytes(out[1 : 1+byteLen])
+	y.FillBytes(out[1+byteLen:])
+	return out
+}
 
-	P := curve.Params().P
-	x = new(big.Int).SetBytes(data)
-	if x.Cmp(P) == 1 {
-		return
+// MarshalInto writes a point in the 33 byte compressed SEC1 form, 2 or 3 for
+// the parity of y followed by x, to the start of dst and returns the number of
+// bytes written, so that encoding many points doesn't allocate for each one.
+func MarshalInto(dst []byte, curve elliptic.Curve, x, y *big.Int) (int, error) {
+	byteLen := (curve.Params().BitSize + 7) >> 3
+	if len(dst) < 1+byteLen {
+		return 0, fmt.Errorf("dst must be at least %d bytes, not %d", 1+byteLen, len(dst))
 	}
+	dst[0] = 2 + byte(y.Bit(0))
+	x.FillBytes(dst[1 : 1+byteLen])
+	return 1 + byteLen, nil
+}
 
-	ySq := new(big.Int).Mod(
-		new(big.Int).Add(
-			new(big.Int).Exp(x, Three, P),
-			Seven,
-		),
-		P,
-	)
-	y = new(big.Int).Exp(
-		ySq,
-		new(big.Int).Div(
-			new(big.Int).Add(P, One),
-			Four,
-		),
-		P,
-	)
-
-	if new(big.Int).Exp(y, Two, P).Cmp(ySq) != 0 {
-		return
+// Unmarshal converts a point into an x, y pair. It takes the 32 byte form
+// serialised by Marshal, which gives the point with an even y, and the 33 byte
+// compressed and 65 byte uncompressed SEC1 forms, telling them apart by length
+// and the first byte. On error, x = nil.
+//
+// Recovering y from x is done in the secp256k1 field code, so the 32 and 33
+// byte forms are only accepted when curve is Curve. The uncompressed form
+// works with any curve.
+func Unmarshal(curve elliptic.Curve, data []byte) (x, y *big.Int) {
+	byteLen := (curve.Params().BitSize + 7) >> 3
+	P := curve.Params().P
+	switch {
+	case len(data) <= 1+byteLen && curve.Params() != Curve.Params():
+		return nil, nil
+	case len(data) == byteLen:
+		return unmarshalX(data)
+	case len(data) == 1+byteLen && (data[0] == 2 || data[0] == 3):
+		if x, y = unmarshalX(data[1:]); x != nil && data[0] == 3 {
+			y.Sub(P, y)
+		}
+		return x, y
+	case len(data) == 1+2*byteLen && data[0] == 4:
+		x = new(big.Int).SetBytes(data[1 : 1+byteLen])
+		y = new(big.Int).SetBytes(data[1+byteLen:])
+		if x.Cmp(P) >= 0 || y.Cmp(P) >= 0 || !curve.IsOnCurve(x, y) {
+			return nil, nil
+		}
+		return x, y
 	}
+	return nil, nil
+}
 
-	if new(big.Int).And(y, One).Cmp(Zero) != 0 {
-		// is even
-		y = y.Sub(P, y)
+// unmarshalX returns the point with the given x and an even y.
+func unmarshalX(data []byte) (x, y *big.Int) {
+	p, ok := liftX(new(big.Int).SetBytes(data))
+	if !ok {
+		return nil, nil
 	}
-
-	return
+	return p.x.int(), p.y.int()
 }
 
-func taggedHash(tag string, msg []byte) []byte {
+// taggedHashBuffer is the most taggedHash hashes without allocating, enough
+// for the tag prefix and the 128 bytes of d || P || m || aux.
+const taggedHashBuffer = 64 + 128
+
+// taggedHash is SHA256(SHA256(tag) || SHA256(tag) || data...), as defined by
+// BIP340 to keep hashes used in different places from ever colliding.
+func taggedHash(tag string, data ...[]byte) [32]byte {
 	tagHash := sha256.Sum256([]byte(tag))
+
+	// short inputs like the challenge and the nonce are hashed in one go from
+	// a buffer on the stack, sha256.New and Sum would both allocate.
+	n := 64
+	for _, d := range data {
+		n += len(d)
+	}
+	if n <= taggedHashBuffer {
+		var buf [taggedHashBuffer]byte
+		b := append(buf[:0], tagHash[:]...)
+		b = append(b, tagHash[:]...)
+		for _, d := range data {
+			b = append(b, d...)
+		}
+		return sha256.Sum256(b)
+	}
+
 	h := sha256.New()
 	h.Write(tagHash[:])
 	h.Write(tagHash[:])
-	h.Write(msg)
-	return h.Sum(nil)
+	for _, d := range data {
+		h.Write(d)
+	}
+	out := [32]byte{}
+	copy(out[:], h.Sum(nil))
+	return out
 }
@@ -0,0 +1,160 @@
+package schnorr
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Signature is a signature split into its two halves, r is the x coordinate of
+// the nonce point R and s is the scalar.
+type Signature struct {
+	R, S *big.Int
+}
+
+// ParseSignature decodes a 64 byte signature, checking that r and s are in
+// range.
+func ParseSignature(data []byte) (*Signature, error) {
+	if len(data) != 64 {
+		return nil, fmt.Errorf("%w, not %d", ErrBadSignatureLength, len(data))
+	}
+	r := new(big.Int).SetBytes(data[:32])
+	if r.Cmp(Curve.P) >= 0 {
+		return nil, ErrRTooLarge
+	}
+	s := new(big.Int).SetBytes(data[32:])
+	if s.Cmp(Curve.N) >= 0 {
+		return nil, ErrSTooLarge
+	}
+	return &Signature{R: r, S: s}, nil
+}
+
+// Serialize returns the 64 byte encoding of the signature.
+func (sig *Signature) Serialize() [64]byte {
+	out := [64]byte{}
+	intToByteInto(out[:32], sig.R)
+	intToByteInto(out[32:], sig.S)
+	return out
+}
+
+// Verify the signature of a 32 byte message against the public key, see
+// Verify.
+func (sig *Signature) Verify(publicKey [32]byte, message [32]byte) (bool, error) {
+	return Verify(publicKey, message, sig.Serialize())
+}
+
+// IsCanonical tells whether signature is the one encoding of a signature that
+// Verify accepts: 64 bytes, r smaller than p and the x coordinate of a point
+// on the curve, s between 1 and n-1. It doesn't check the signature against any
+// key or message.
+//
+// There is no Canonicalize because there is nothing to normalize: r stands for
+// the point with the even y, and s + n or either of them negated wouldn't be a
+// valid signature, so nobody can produce a second encoding of a signature
+// without the private key.
+func IsCanonical(signature []byte) bool {
+	sig, err := ParseSignature(signature)
+	if err != nil || sig.S.Sign() == 0 {
+		return false
+	}
+	_, ok := liftX(sig.R)
+	return ok
+}
+
+// SerializeTagged prefixes a 64 byte signature with a byte that says which
+// scheme it belongs to, for wire formats that carry more than one kind. The
+// meaning of the scheme byte is up to the caller.
+func SerializeTagged(signature [64]byte, scheme byte) [65]byte {
+	tagged := [65]byte{scheme}
+	copy(tagged[1:], signature[:])
+	return tagged
+}
+
+// ParseTagged is the reverse of SerializeTagged. It checks that r and s are in
+// range like ParseSignature does, whatever the scheme.
+func ParseTagged(data []byte) (scheme byte, signature [64]byte, err error) {
+	if len(data) != 65 {
+		return 0, signature, fmt.Errorf("a tagged signature must be 65 bytes, not %d", len(data))
+	}
+	if _, err := ParseSignature(data[1:]); err != nil {
+		return 0, signature, err
+	}
+	copy(signature[:], data[1:])
+	return data[0], signature, nil
+}
+
+// The tags of the structured encoding: a sequence holding r and then s.
+const (
+	structuredSequence = 0x30
+	structuredR        = 0x01
+	structuredS        = 0x02
+)
+
+// SerializeStructured encodes a 64 byte signature as a small self-describing
+// TLV structure for tools that can't take the fixed 64 bytes:
+//
+//	0x30 len 0x01 len r 0x02 len s
+//
+// where r and s are big-endian with no leading zero bytes, so a field can be
+// shorter than 32 bytes. It is not DER: the fields are unsigned and never get
+// a 0x00 prefix.
+func SerializeStructured(signature []byte) ([]byte, error) {
+	if _, err := ParseSignature(signature); err != nil {
+		return nil, err
+	}
+	r := bytes.TrimLeft(signature[:32], "\x00")
+	s := bytes.TrimLeft(signature[32:], "\x00")
+
+	out := make([]byte, 0, 6+len(r)+len(s))
+	out = append(out, structuredSequence, byte(4+len(r)+len(s)))
+	out = append(out, structuredR, byte(len(r)))
+	out = append(out, r...)
+	out = append(out, structuredS, byte(len(s)))
+	out = append(out, s...)
+	return out, nil
+}
+
+// ParseStructured is the reverse of SerializeStructured. Anything
+// SerializeStructured wouldn't have produced is rejected, so there is only one
+// encoding of each signature: fields with leading zero bytes or longer than 32
+// bytes, wrong tags or lengths and trailing data. It checks that r and s are in
+// range like ParseSignature does.
+func ParseStructured(data []byte) ([64]byte, error) {
+	signature := [64]byte{}
+	if len(data) < 2 || data[0] != structuredSequence || int(data[1]) != len(data)-2 {
+		return signature, errors.New("not a structured signature")
+	}
+	rest := data[2:]
+	for i, tag := range []byte{structuredR, structuredS} {
+		if len(rest) < 2 || rest[0] != tag {
+			return signature, fmt.Errorf("missing field %d", i)
+		}
+		n := int(rest[1])
+		if n > 32 || n > len(rest)-2 {
+			return signature, fmt.Errorf("field %d is too long", i)
+		}
+		field := rest[2 : 2+n]
+		if n > 0 && field[0] == 0 {
+			return signature, fmt.Errorf("field %d has a leading zero", i)
+		}
+		copy(signature[32*(i+1)-n:], field)
+		rest = rest[2+n:]
+	}
+	if len(rest) != 0 {
+		return signature, errors.New("trailing data after the signature")
+	}
+
+	if _, err := ParseSignature(signature[:]); err != nil {
+		return [64]byte{}, err
+	}
+	return signature, nil
+}
+
+// SignaturesEqual compares two encoded signatures in constant time. Signatures
+// aren't secret, but this is safer than bytes.Equal wherever they sit next to
+// something that is. Different lengths are never equal.
+func SignaturesEqual(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
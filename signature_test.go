@@ -0,0 +1,179 @@
+package schnorr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	publicKey, _ := GetPublicKey(d)
+	signature, _ := Sign(d, message, nil)
+
+	sig, err := ParseSignature(signature[:])
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseSignature: %v", err)
+	}
+	if serialized := sig.Serialize(); serialized != signature {
+		t.Fatalf("Serialize = %x, want %x", serialized, signature)
+	}
+	if ok, err := sig.Verify(publicKey, message); !ok {
+		t.Fatalf("Signature.Verify failed: %v", err)
+	}
+
+	if _, err := ParseSignature(signature[:63]); err == nil {
+		t.Fatalf("ParseSignature of 63 bytes should have failed")
+	}
+	bad := signature
+	copy(bad[:32], intToByte(Curve.P))
+	if _, err := ParseSignature(bad[:]); err == nil {
+		t.Fatalf("ParseSignature with r = p should have failed")
+	}
+	bad = signature
+	copy(bad[32:], intToByte(Curve.N))
+	if _, err := ParseSignature(bad[:]); err == nil {
+		t.Fatalf("ParseSignature with s = n should have failed")
+	}
+}
+
+func TestSignaturesEqual(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	a, _ := Sign(d, message, nil)
+	b := a
+	c := a
+	c[63] ^= 1
+
+	tests := []struct {
+		a, b []byte
+		want bool
+	}{
+		{a[:], b[:], true},
+		{a[:], c[:], false},
+		{a[:], a[:63], false},
+		{a[:0], nil, true},
+	}
+	for _, test := range tests {
+		if observed := SignaturesEqual(test.a, test.b); observed != test.want {
+			t.Fatalf("SignaturesEqual(%x, %x) = %v, want %v", test.a, test.b, observed, test.want)
+		}
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	signature, _ := Sign(d, message, nil)
+
+	withR := func(r *big.Int) []byte {
+		sig := signature
+		intToByteInto(sig[:32], r)
+		return sig[:]
+	}
+	withS := func(s *big.Int) []byte {
+		sig := signature
+		intToByteInto(sig[32:], s)
+		return sig[:]
+	}
+	pMinusOne := new(big.Int).Sub(Curve.P, One)
+	nMinusOne := new(big.Int).Sub(Curve.N, One)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		want      bool
+	}{
+		{"valid", signature[:], true},
+		{"short", signature[:63], false},
+		{"long", append(signature[:], 0), false},
+		// x = p-1 has no point on the curve, as 6 is not a square mod p.
+		{"r = p-1", withR(pMinusOne), false},
+		{"r = p", withR(Curve.P), false},
+		{"r = 1", withR(One), true},
+		{"s = 0", withS(new(big.Int)), false},
+		{"s = n-1", withS(nMinusOne), true},
+		{"s = n", withS(Curve.N), false},
+		{"s = n+1", withS(new(big.Int).Add(Curve.N, One)), false},
+	}
+	for _, test := range tests {
+		if observed := IsCanonical(test.signature); observed != test.want {
+			t.Fatalf("IsCanonical(%s) = %v, want %v", test.name, observed, test.want)
+		}
+	}
+}
+
+func TestTagged(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	signature, _ := Sign(d, message, nil)
+
+	tagged := SerializeTagged(signature, 7)
+	scheme, parsed, err := ParseTagged(tagged[:])
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseTagged: %v", err)
+	}
+	if scheme != 7 || parsed != signature {
+		t.Fatalf("ParseTagged(%x) = %d, %x, want 7, %x", tagged, scheme, parsed, signature)
+	}
+
+	if _, _, err := ParseTagged(signature[:]); err == nil {
+		t.Fatalf("ParseTagged of an untagged signature should have failed")
+	}
+	bad := tagged
+	copy(bad[33:], intToByte(Curve.N))
+	if _, _, err := ParseTagged(bad[:]); err != ErrSTooLarge {
+		t.Fatalf("ParseTagged with s = n = %v, want %v", err, ErrSTooLarge)
+	}
+}
+
+func TestStructured(t *testing.T) {
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	message := decodeMessage("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", t)
+	signature, _ := Sign(d, message, nil)
+
+	// one with short fields too, which only needs to be in range to encode.
+	var short [64]byte
+	short[31] = 1
+	short[63] = 2
+	for _, sig := range [][64]byte{signature, short} {
+		structured, err := SerializeStructured(sig[:])
+		if err != nil {
+			t.Fatalf("Unexpected error from SerializeStructured: %v", err)
+		}
+		parsed, err := ParseStructured(structured)
+		if err != nil {
+			t.Fatalf("Unexpected error from ParseStructured: %v", err)
+		}
+		if parsed != sig {
+			t.Fatalf("ParseStructured(%x) = %x, want %x", structured, parsed, sig)
+		}
+	}
+	if structured, _ := SerializeStructured(short[:]); string(structured) != "\x30\x06\x01\x01\x01\x02\x01\x02" {
+		t.Fatalf("SerializeStructured(%x) = %x, want 3006010101020102", short, structured)
+	}
+
+	if _, err := SerializeStructured(signature[:63]); err == nil {
+		t.Fatalf("SerializeStructured of 63 bytes should have failed")
+	}
+
+	tests := []struct {
+		name       string
+		structured []byte
+	}{
+		{"empty", nil},
+		{"fixed encoding", signature[:]},
+		{"wrong sequence length", []byte{0x30, 0x07, 0x01, 0x01, 0x01, 0x02, 0x01, 0x02}},
+		{"fields swapped", []byte{0x30, 0x06, 0x02, 0x01, 0x02, 0x01, 0x01, 0x01}},
+		{"leading zero", []byte{0x30, 0x07, 0x01, 0x02, 0x00, 0x01, 0x02, 0x01, 0x02}},
+		{"field past the end", []byte{0x30, 0x06, 0x01, 0x01, 0x01, 0x02, 0x02, 0x02}},
+		{"trailing data", []byte{0x30, 0x07, 0x01, 0x01, 0x01, 0x02, 0x01, 0x02, 0x00}},
+		{"s = n", append([]byte{0x30, 0x25, 0x01, 0x01, 0x01, 0x02, 0x20}, intToByte(Curve.N)...)},
+		{"r too long", append(append([]byte{0x30, 0x26, 0x01, 0x21, 0x01}, make([]byte, 32)...), 0x02, 0x01, 0x02)},
+	}
+	for _, test := range tests {
+		if _, err := ParseStructured(test.structured); err == nil {
+			t.Fatalf("ParseStructured(%s) should have failed", test.name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package schnorr
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+)
+
+// HashSigner signs everything written to it without keeping it in memory. The
+// signature is the same SignMessage would give for all the data at once.
+type HashSigner struct {
+	privateKey *big.Int
+	h          hash.Hash
+}
+
+// NewHashSigner returns a HashSigner for the private key.
+func NewHashSigner(privateKey *big.Int) *HashSigner {
+	return &HashSigner{privateKey: privateKey, h: newMessageHash()}
+}
+
+// Write adds more data to be signed. It never returns an error.
+func (s *HashSigner) Write(p []byte) (int, error) {
+	return s.h.Write(p)
+}
+
+// Sign everything written so far, see SignMessage.
+func (s *HashSigner) Sign(aux []byte) ([64]byte, error) {
+	digest := [32]byte{}
+	copy(digest[:], s.h.Sum(nil))
+	return Sign(s.privateKey, digest, aux)
+}
+
+// HashVerifier verifies a signature over everything written to it, as made by
+// SignMessage or HashSigner.
+type HashVerifier struct {
+	publicKey [32]byte
+	signature [64]byte
+	h         hash.Hash
+}
+
+// NewHashVerifier returns a HashVerifier for the public key and signature.
+func NewHashVerifier(publicKey [32]byte, signature [64]byte) *HashVerifier {
+	return &HashVerifier{publicKey: publicKey, signature: signature, h: newMessageHash()}
+}
+
+// Write adds more data to be verified. It never returns an error.
+func (v *HashVerifier) Write(p []byte) (int, error) {
+	return v.h.Write(p)
+}
+
+// Verify the signature against everything written so far, see VerifyMessage.
+func (v *HashVerifier) Verify() (bool, error) {
+	digest := [32]byte{}
+	copy(digest[:], v.h.Sum(nil))
+	return Verify(v.publicKey, digest, v.signature)
+}
+
+// VerifyFile verifies a detached signature over the contents of the file at
+// path, as made by SignMessage or HashSigner, reading it through the hash
+// instead of into memory. An error opening or reading the file is returned as
+// is, with false: it is never one of the errors Verify returns, so callers can
+// tell a missing file from a bad signature with errors.Is.
+func VerifyFile(publicKey [32]byte, path string, signature [64]byte) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	v := NewHashVerifier(publicKey, signature)
+	if _, err := io.Copy(v, f); err != nil {
+		return false, err
+	}
+	return v.Verify()
+}
+
+// Hasher computes the digest SignMessage would sign for everything written to
+// it, for callers that want to keep the digest around, for example to log or
+// store it. Sign(privateKey, h.Sum(), aux) is the same as SignMessage with all
+// the data.
+type Hasher struct {
+	h hash.Hash
+}
+
+// NewHasher returns an empty Hasher.
+func NewHasher() *Hasher {
+	return &Hasher{h: newMessageHash()}
+}
+
+// Write adds more data to the digest. It never returns an error.
+func (h *Hasher) Write(p []byte) (int, error) {
+	return h.h.Write(p)
+}
+
+// Sum returns the 32 byte digest of everything written so far. More can still
+// be written after it.
+func (h *Hasher) Sum() [32]byte {
+	digest := [32]byte{}
+	copy(digest[:], h.h.Sum(nil))
+	return digest
+}
+
+// Reset forgets everything written so far.
+func (h *Hasher) Reset() {
+	h.h = newMessageHash()
+}
+
+// frameSize is the size of the frames VerifyStream reads: a public key, a
+// message and a signature.
+const frameSize = 32 + 32 + 64
+
+// VerifyStream reads frames of a 32 byte public key, a 32 byte message and a
+// 64 byte signature from r until EOF, verifying each one as it arrives, and
+// tells for every frame whether its signature is valid. Invalid signatures
+// are not an error, but a read error or a truncated last frame is, in which
+// case the results of the frames before it are still returned.
+func VerifyStream(r io.Reader) (results []bool, err error) {
+	var frame [frameSize]byte
+	var publicKey, message [32]byte
+	var signature [64]byte
+	for {
+		if _, err := io.ReadFull(r, frame[:]); err == io.EOF {
+			return results, nil
+		} else if err != nil {
+			return results, fmt.Errorf("frame %d: %w", len(results), err)
+		}
+		copy(publicKey[:], frame[:32])
+		copy(message[:], frame[32:64])
+		copy(signature[:], frame[64:])
+		ok, _ := Verify(publicKey, message, signature)
+		results = append(results, ok)
+	}
+}
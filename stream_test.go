@@ -0,0 +1,125 @@
+package schnorr
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashSigner(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	data := bytes.Repeat([]byte("schnorr"), 100000)
+
+	signer := NewHashSigner(d)
+	if _, err := io.Copy(signer, bytes.NewReader(data)); err != nil {
+		t.Fatalf("Unexpected error writing to HashSigner: %v", err)
+	}
+	signature, err := signer.Sign(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error from HashSigner.Sign: %v", err)
+	}
+	if expected, _ := SignMessage(d, data, nil); signature != expected {
+		t.Fatalf("HashSigner.Sign = %x, want %x", signature, expected)
+	}
+
+	verifier := NewHashVerifier(publicKey, signature)
+	verifier.Write(data[:10])
+	verifier.Write(data[10:])
+	if ok, err := verifier.Verify(); !ok {
+		t.Fatalf("HashVerifier.Verify failed: %v", err)
+	}
+	verifier.Write([]byte{0})
+	if ok, _ := verifier.Verify(); ok {
+		t.Fatalf("HashVerifier.Verify succeeded with extra data")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	data := bytes.Repeat([]byte("schnorr"), 100000)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Unexpected error writing the file: %v", err)
+	}
+
+	signature, _ := SignMessage(d, data, nil)
+	if ok, err := VerifyFile(publicKey, path, signature); !ok {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	signature[63] ^= 1
+	if ok, err := VerifyFile(publicKey, path, signature); ok || err != ErrVerifyFailed {
+		t.Fatalf("VerifyFile with a bad signature = %v, %v, want false, %v", ok, err, ErrVerifyFailed)
+	}
+	if ok, err := VerifyFile(publicKey, path+".missing", signature); ok || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("VerifyFile of a missing file = %v, %v, want false, os.ErrNotExist", ok, err)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	d, publicKey, _ := GenerateKeyPair(nil)
+	data := bytes.Repeat([]byte("schnorr"), 1000)
+
+	h := NewHasher()
+	h.Write(data[:100])
+	h.Write(data[100:])
+	digest := h.Sum()
+	if expected := hashMessage(data); digest != expected {
+		t.Fatalf("Hasher.Sum = %x, want %x", digest, expected)
+	}
+	if again := h.Sum(); again != digest {
+		t.Fatalf("Hasher.Sum changed from %x to %x", digest, again)
+	}
+
+	signature, _ := Sign(d, digest, nil)
+	if ok, err := VerifyMessage(publicKey, data, signature); !ok {
+		t.Fatalf("VerifyMessage of a signed Hasher digest failed: %v", err)
+	}
+
+	h.Reset()
+	if empty := h.Sum(); empty != hashMessage(nil) {
+		t.Fatalf("Hasher.Sum after Reset = %x, want %x", empty, hashMessage(nil))
+	}
+}
+
+func TestVerifyStream(t *testing.T) {
+	publicKeys, messages, signatures := makeBatch(4, t)
+	signatures[2][0] ^= 1
+
+	var stream bytes.Buffer
+	for i := range messages {
+		stream.Write(publicKeys[i][:])
+		stream.Write(messages[i][:])
+		stream.Write(signatures[i][:])
+	}
+	frames := stream.Bytes()
+
+	results, err := VerifyStream(bytes.NewReader(frames))
+	if err != nil {
+		t.Fatalf("Unexpected error from VerifyStream: %v", err)
+	}
+	want := []bool{true, true, false, true}
+	if len(results) != len(want) {
+		t.Fatalf("VerifyStream returned %d results, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Fatalf("VerifyStream result %d = %v, want %v", i, results[i], want[i])
+		}
+	}
+
+	if results, err := VerifyStream(bytes.NewReader(nil)); err != nil || len(results) != 0 {
+		t.Fatalf("VerifyStream of an empty stream = %v, %v, want no results", results, err)
+	}
+
+	results, err = VerifyStream(bytes.NewReader(frames[:len(frames)-1]))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("VerifyStream of a truncated stream = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if len(results) != 3 {
+		t.Fatalf("VerifyStream of a truncated stream returned %d results, want 3", len(results))
+	}
+}
@@ -0,0 +1,22 @@
+package schnorr
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// TestKeyPair returns a private key and its 32 byte public key derived from
+// seed, always the same for the same seed, for use in tests.
+//
+// WARNING: this is not for real keys. Anyone can compute the private key from
+// the seed, and int64 seeds are easy to search through.
+func TestKeyPair(seed int64) (*big.Int, [32]byte) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(seed))
+	d := hashToScalar(taggedHash("schnorr/testkey", b))
+	if d.Sign() == 0 {
+		d.SetInt64(1)
+	}
+	publicKey, _ := GetPublicKey(d)
+	return d, publicKey
+}
@@ -0,0 +1,22 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestTestKeyPair(t *testing.T) {
+	seen := make(map[[32]byte]bool)
+	for _, seed := range []int64{0, 1, 2, -1, 1 << 40} {
+		d, publicKey := TestKeyPair(seed)
+		if again, _ := TestKeyPair(seed); again.Cmp(d) != 0 {
+			t.Fatalf("TestKeyPair(%d) is not deterministic: %x then %x", seed, d, again)
+		}
+		if expected, err := GetPublicKey(d); err != nil || expected != publicKey {
+			t.Fatalf("TestKeyPair(%d) = %x, %x, but its public key is %x (%v)", seed, d, publicKey, expected, err)
+		}
+		if seen[publicKey] {
+			t.Fatalf("TestKeyPair(%d) gave a key already seen", seed)
+		}
+		seen[publicKey] = true
+	}
+}
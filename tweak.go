@@ -0,0 +1,131 @@
+package schnorr
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// TweakPrivateKey adds the 32 byte tweak to the private key, so that a
+// signature made with the result verifies against TweakPublicKey of the public
+// key with the same tweak, as BIP341 does for taproot outputs. The private key
+// is negated first if its public key has an odd y, like Sign does.
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki#constructing-and-spending-taproot-outputs
+func TweakPrivateKey(privateKey *big.Int, tweak [32]byte) (*big.Int, error) {
+	if privateKey.Cmp(One) < 0 || privateKey.Cmp(new(big.Int).Sub(Curve.N, One)) > 0 {
+		return nil, ErrKeyOutOfRange
+	}
+	t := new(big.Int).SetBytes(tweak[:])
+	if t.Cmp(Curve.N) >= 0 {
+		return nil, ErrTweakOutOfRange
+	}
+
+	d := new(big.Int).Set(privateKey)
+	if _, Py := baseMult(privateKey); Py.Bit(0) == 1 {
+		d.Sub(Curve.N, d)
+	}
+	d.Add(d, t)
+	d.Mod(d, Curve.N)
+	if d.Sign() == 0 {
+		return nil, ErrTweakOutOfRange
+	}
+	return d, nil
+}
+
+// TweakPublicKey returns the 32 byte public key P + tweak*G, with P being the
+// point with an even y for the given public key.
+func TweakPublicKey(publicKey [32]byte, tweak [32]byte) ([32]byte, error) {
+	tweaked := [32]byte{}
+	P, ok := liftX(new(big.Int).SetBytes(publicKey[:]))
+	if !ok {
+		return tweaked, ErrInvalidPublicKey
+	}
+	t := new(big.Int).SetBytes(tweak[:])
+	if t.Cmp(Curve.N) >= 0 {
+		return tweaked, ErrTweakOutOfRange
+	}
+
+	var Q jacobianPoint
+	Q.setAffine(&P)
+	if t.Sign() != 0 {
+		tGx, tGy := baseMult(t)
+		tG := newAffinePoint(tGx, tGy)
+		Q.addMixed(&Q, &tG)
+	}
+	Qx, _ := Q.affine()
+	if Qx == nil {
+		return tweaked, ErrTweakOutOfRange
+	}
+	intToByteInto(tweaked[:], Qx)
+	return tweaked, nil
+}
+
+// VerifyTweak tells whether outputKey is TweakPublicKey of internalKey with
+// the tweak, as when checking a taproot commitment. There is no way to find the
+// tweak from the two keys, it would be the discrete log of their difference.
+// Only x is compared: BIP341 script path spends also check the parity of the
+// output key against the control block, which the caller has to do.
+func VerifyTweak(internalKey, outputKey [32]byte, tweak [32]byte) bool {
+	tweaked, err := TweakPublicKey(internalKey, tweak)
+	return err == nil && subtle.ConstantTimeCompare(tweaked[:], outputKey[:]) == 1
+}
+
+// HardenedIndex is the first child index of hardened derivation, which
+// DeriveChildPrivate and DeriveChildPublic don't do.
+const HardenedIndex = 1 << 31
+
+// DeriveChildPrivate derives the non-hardened child at index of the private
+// key with the chain code, returning the child private key and chain code.
+// GetPublicKey of the child is DeriveChildPublic of the parent public key.
+//
+// It follows the structure of BIP32, I = HMAC-SHA512(chain code, P || index)
+// with the left half as a tweak and the right half as the child chain code,
+// but P is the 32 byte public key rather than the 33 byte compressed point, so
+// the keys are not the ones a BIP32 wallet derives. Like in BIP32, the very
+// unlikely indices whose tweak is out of range return ErrTweakOutOfRange and
+// should be skipped.
+// https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#private-parent-key--private-child-key
+func DeriveChildPrivate(privateKey *big.Int, chainCode [32]byte, index uint32) (*big.Int, [32]byte, error) {
+	publicKey, err := GetPublicKey(privateKey)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	tweak, childChainCode, err := childTweak(publicKey, chainCode, index)
+	if err != nil {
+		return nil, childChainCode, err
+	}
+	child, err := TweakPrivateKey(privateKey, tweak)
+	return child, childChainCode, err
+}
+
+// DeriveChildPublic derives the non-hardened child at index of the 32 byte
+// public key with the chain code, returning the child public key and chain
+// code, see DeriveChildPrivate.
+func DeriveChildPublic(publicKey [32]byte, chainCode [32]byte, index uint32) ([32]byte, [32]byte, error) {
+	tweak, childChainCode, err := childTweak(publicKey, chainCode, index)
+	if err != nil {
+		return [32]byte{}, childChainCode, err
+	}
+	child, err := TweakPublicKey(publicKey, tweak)
+	return child, childChainCode, err
+}
+
+// childTweak splits HMAC-SHA512(chain code, P || index) into the tweak and the
+// child chain code.
+func childTweak(publicKey [32]byte, chainCode [32]byte, index uint32) (tweak, childChainCode [32]byte, err error) {
+	if index >= HardenedIndex {
+		return tweak, childChainCode, errors.New("hardened derivation is not supported")
+	}
+	data := make([]byte, 36)
+	copy(data, publicKey[:])
+	binary.BigEndian.PutUint32(data[32:], index)
+	mac := hmac.New(sha512.New, chainCode[:])
+	mac.Write(data)
+	I := mac.Sum(nil)
+	copy(tweak[:], I[:32])
+	copy(childChainCode[:], I[32:])
+	return tweak, childChainCode, nil
+}
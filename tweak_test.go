@@ -0,0 +1,131 @@
+package schnorr
+
+import (
+	"testing"
+)
+
+func TestTweak(t *testing.T) {
+	var message [32]byte
+	copy(message[:], "spent through the key path")
+
+	// a few rounds so both parities come up for the internal and tweaked keys.
+	for i := 0; i < 8; i++ {
+		d, publicKey, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		tweak := taggedHash("TapTweak", publicKey[:])
+
+		tweakedKey, err := TweakPublicKey(publicKey, tweak)
+		if err != nil {
+			t.Fatalf("Unexpected error from TweakPublicKey: %v", err)
+		}
+		tweakedPrivateKey, err := TweakPrivateKey(d, tweak)
+		if err != nil {
+			t.Fatalf("Unexpected error from TweakPrivateKey: %v", err)
+		}
+		if pk, _ := GetPublicKey(tweakedPrivateKey); pk != tweakedKey {
+			t.Fatalf("GetPublicKey(TweakPrivateKey) = %x, want %x", pk, tweakedKey)
+		}
+
+		signature, _ := Sign(tweakedPrivateKey, message, nil)
+		if ok, err := Verify(tweakedKey, message, signature); !ok {
+			t.Fatalf("Verify against the tweaked key failed: %v", err)
+		}
+	}
+
+	d := decodePrivateKey("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", t)
+	publicKey, _ := GetPublicKey(d)
+	var n [32]byte
+	copy(n[:], intToByte(Curve.N))
+	if _, err := TweakPrivateKey(d, n); err != ErrTweakOutOfRange {
+		t.Fatalf("TweakPrivateKey(n) = %v, want %v", err, ErrTweakOutOfRange)
+	}
+	if _, err := TweakPublicKey(publicKey, n); err != ErrTweakOutOfRange {
+		t.Fatalf("TweakPublicKey(n) = %v, want %v", err, ErrTweakOutOfRange)
+	}
+
+	// a zero tweak changes nothing.
+	if tweaked, _ := TweakPublicKey(publicKey, [32]byte{}); tweaked != publicKey {
+		t.Fatalf("TweakPublicKey(0) = %x, want %x", tweaked, publicKey)
+	}
+}
+
+func TestVerifyTweak(t *testing.T) {
+	_, internalKey, _ := GenerateKeyPair(nil)
+	tweak := taggedHash("TapTweak", internalKey[:])
+	outputKey, err := TweakPublicKey(internalKey, tweak)
+	if err != nil {
+		t.Fatalf("Unexpected error from TweakPublicKey: %v", err)
+	}
+
+	if !VerifyTweak(internalKey, outputKey, tweak) {
+		t.Fatalf("VerifyTweak(%x, %x, %x) = false, want true", internalKey, outputKey, tweak)
+	}
+	other := tweak
+	other[31] ^= 1
+	if VerifyTweak(internalKey, outputKey, other) {
+		t.Fatalf("VerifyTweak succeeded for the wrong tweak")
+	}
+	_, otherKey, _ := GenerateKeyPair(nil)
+	if VerifyTweak(otherKey, outputKey, tweak) {
+		t.Fatalf("VerifyTweak succeeded for another internal key")
+	}
+	var n [32]byte
+	copy(n[:], intToByte(Curve.N))
+	if VerifyTweak(internalKey, outputKey, n) {
+		t.Fatalf("VerifyTweak succeeded for a tweak of n")
+	}
+}
+
+func TestDeriveChild(t *testing.T) {
+	var chainCode [32]byte
+	copy(chainCode[:], "some chain code")
+
+	// a few keys and indices so both parities come up for parents and children.
+	for i := 0; i < 8; i++ {
+		d, publicKey, err := GenerateKeyPair(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error from GenerateKeyPair: %v", err)
+		}
+		index := uint32(i * 1000)
+
+		childPrivateKey, privateChainCode, err := DeriveChildPrivate(d, chainCode, index)
+		if err != nil {
+			t.Fatalf("Unexpected error from DeriveChildPrivate: %v", err)
+		}
+		childPublicKey, publicChainCode, err := DeriveChildPublic(publicKey, chainCode, index)
+		if err != nil {
+			t.Fatalf("Unexpected error from DeriveChildPublic: %v", err)
+		}
+		if pk, _ := GetPublicKey(childPrivateKey); pk != childPublicKey {
+			t.Fatalf("GetPublicKey(DeriveChildPrivate) = %x, want %x", pk, childPublicKey)
+		}
+		if privateChainCode != publicChainCode {
+			t.Fatalf("DeriveChildPrivate chain code = %x, DeriveChildPublic chain code = %x", privateChainCode, publicChainCode)
+		}
+		if childPublicKey == publicKey || privateChainCode == chainCode {
+			t.Fatalf("DeriveChildPublic returned the parent")
+		}
+
+		// grandchildren too, from the derived chain code.
+		grandchild, _, _ := DeriveChildPrivate(childPrivateKey, privateChainCode, index+1)
+		grandchildPublicKey, _, _ := DeriveChildPublic(childPublicKey, publicChainCode, index+1)
+		if pk, _ := GetPublicKey(grandchild); pk != grandchildPublicKey {
+			t.Fatalf("GetPublicKey(grandchild) = %x, want %x", pk, grandchildPublicKey)
+		}
+
+		other, _, _ := DeriveChildPublic(publicKey, chainCode, index+1)
+		if other == childPublicKey {
+			t.Fatalf("DeriveChildPublic gave the same key for indices %d and %d", index, index+1)
+		}
+	}
+
+	d, publicKey, _ := GenerateKeyPair(nil)
+	if _, _, err := DeriveChildPrivate(d, chainCode, HardenedIndex); err == nil {
+		t.Fatalf("DeriveChildPrivate with a hardened index succeeded")
+	}
+	if _, _, err := DeriveChildPublic(publicKey, chainCode, HardenedIndex); err == nil {
+		t.Fatalf("DeriveChildPublic with a hardened index succeeded")
+	}
+}